@@ -0,0 +1,237 @@
+// Package config holds the settings of the book service. They are read
+// from, in decreasing order of precedence, command line flags, environment
+// variables, an optional YAML or JSON file and built-in defaults.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Config is the effective configuration of the book service.
+type Config struct {
+	// ServiceName identifies the service in traces.
+	ServiceName string        `yaml:"service_name" json:"service_name"`
+	Server      ServerConfig  `yaml:"server" json:"server"`
+	Store       StoreConfig   `yaml:"store" json:"store"`
+	Tracing     TracingConfig `yaml:"tracing" json:"tracing"`
+	Metrics     MetricsConfig `yaml:"metrics" json:"metrics"`
+	Trash       TrashConfig   `yaml:"trash" json:"trash"`
+}
+
+type ServerConfig struct {
+	// Addr is the host:port the HTTP server listens on.
+	Addr string `yaml:"addr" json:"addr"`
+	// ReadTimeout bounds reading a request, headers and body.
+	ReadTimeout Duration `yaml:"read_timeout" json:"read_timeout"`
+	// WriteTimeout bounds handling a request and writing its response.
+	WriteTimeout Duration `yaml:"write_timeout" json:"write_timeout"`
+	// IdleTimeout is how long keep-alive connections wait for the next
+	// request.
+	IdleTimeout Duration `yaml:"idle_timeout" json:"idle_timeout"`
+	// DrainPeriod is how long the server keeps serving, while reporting
+	// it is not ready, after being asked to stop. It gives load balancers
+	// time to send traffic elsewhere.
+	DrainPeriod Duration `yaml:"drain_period" json:"drain_period"`
+	// ShutdownTimeout bounds waiting for in-flight requests to complete
+	// once the server stops accepting connections.
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+}
+
+type StoreConfig struct {
+	// Type is the storage backend, sqlite or memory.
+	Type string `yaml:"type" json:"type"`
+	// Path is the SQLite database file.
+	Path string `yaml:"path" json:"path"`
+}
+
+type TracingConfig struct {
+	// Exporter is where spans are sent: otlp, stdout or none.
+	Exporter string `yaml:"exporter" json:"exporter"`
+	// Endpoint is the base URL of the OTLP/HTTP collector, such as
+	// http://localhost:4318. Empty uses the exporter default.
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	// Headers are sent with every export, typically to authenticate.
+	// Their values are secret.
+	Headers map[string]string `yaml:"headers" json:"headers"`
+}
+
+type MetricsConfig struct {
+	// Exporter is how metrics are exported: prometheus serves them at
+	// /metrics, expvar serves them as JSON at /debug/vars, otlp pushes them
+	// to the collector of tracing.endpoint with the tracing headers, stdout
+	// prints them and none discards them.
+	Exporter string `yaml:"exporter" json:"exporter"`
+	// Interval is how often otlp and stdout export metrics.
+	Interval Duration `yaml:"interval" json:"interval"`
+	// SpanKinds are the kinds of spans request metrics are reported for:
+	// server, consumer and internal spans as requests to the service,
+	// client and producer spans as calls to its dependencies.
+	SpanKinds []string `yaml:"span_kinds" json:"span_kinds"`
+	// MaxEndpoints is how many endpoints request metrics tell apart; further
+	// ones are reported as "other".
+	MaxEndpoints int `yaml:"max_endpoints" json:"max_endpoints"`
+	// MaxPeers is how many dependencies the metrics of calls tell apart;
+	// further ones are reported as "other".
+	MaxPeers int `yaml:"max_peers" json:"max_peers"`
+	// EndpointNormalizer is how endpoint names are made safe for metrics:
+	// route replaces the identifiers in them with placeholders, simple only
+	// replaces unsafe characters.
+	EndpointNormalizer string `yaml:"endpoint_normalizer" json:"endpoint_normalizer"`
+}
+
+type TrashConfig struct {
+	// Retention is how long deleted books are kept when purging the trash
+	// without an explicit age.
+	Retention Duration `yaml:"retention" json:"retention"`
+}
+
+// Duration is a time.Duration written as a string such as "720h".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Default returns the configuration used for settings that are not set.
+func Default() Config {
+	return Config{
+		ServiceName: "book-service",
+		Server: ServerConfig{
+			Addr:            ":8090",
+			ReadTimeout:     Duration(10 * time.Second),
+			WriteTimeout:    Duration(30 * time.Second),
+			IdleTimeout:     Duration(2 * time.Minute),
+			DrainPeriod:     Duration(5 * time.Second),
+			ShutdownTimeout: Duration(15 * time.Second),
+		},
+		Store:   StoreConfig{Type: "sqlite", Path: "test.db"},
+		Tracing: TracingConfig{Exporter: "otlp"},
+		Metrics: MetricsConfig{
+			Exporter:           "prometheus",
+			Interval:           Duration(time.Minute),
+			SpanKinds:          []string{"server", "client"},
+			MaxEndpoints:       200,
+			MaxPeers:           50,
+			EndpointNormalizer: "route",
+		},
+		Trash: TrashConfig{Retention: Duration(30 * 24 * time.Hour)},
+	}
+}
+
+// Validate checks the configuration, reporting every invalid setting.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if c.ServiceName == "" {
+		invalid("service_name", "is required")
+	}
+	if _, port, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		invalid("server.addr", "must be host:port, got %q", c.Server.Addr)
+	} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		invalid("server.addr", "has an invalid port %q", port)
+	}
+	for _, timeout := range []struct {
+		key string
+		d   Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	} {
+		if timeout.d <= 0 {
+			invalid(timeout.key, "must be positive")
+		}
+	}
+	if c.Server.DrainPeriod < 0 {
+		invalid("server.drain_period", "must not be negative")
+	}
+	switch c.Store.Type {
+	case "sqlite":
+		if c.Store.Path == "" {
+			invalid("store.path", "is required for the sqlite store")
+		}
+	case "memory":
+	default:
+		invalid("store.type", "must be sqlite or memory, got %q", c.Store.Type)
+	}
+	switch c.Tracing.Exporter {
+	case "otlp", "stdout", "none":
+	default:
+		invalid("tracing.exporter", "must be otlp, stdout or none, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.Endpoint != "" {
+		u, err := url.Parse(c.Tracing.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("tracing.endpoint", "must be an http or https URL")
+		}
+	}
+	switch c.Metrics.Exporter {
+	case "prometheus", "expvar", "otlp", "stdout", "none":
+	default:
+		invalid("metrics.exporter", "must be prometheus, expvar, otlp, stdout or none, got %q", c.Metrics.Exporter)
+	}
+	if c.Metrics.Interval <= 0 {
+		invalid("metrics.interval", "must be positive")
+	}
+	if len(c.Metrics.SpanKinds) == 0 {
+		invalid("metrics.span_kinds", "is required")
+	}
+	for _, kind := range c.Metrics.SpanKinds {
+		switch kind {
+		case "server", "client", "producer", "consumer", "internal":
+		default:
+			invalid("metrics.span_kinds", "must list server, client, producer, consumer or internal, got %q", kind)
+		}
+	}
+	if c.Metrics.MaxEndpoints <= 0 {
+		invalid("metrics.max_endpoints", "must be positive")
+	}
+	if c.Metrics.MaxPeers <= 0 {
+		invalid("metrics.max_peers", "must be positive")
+	}
+	switch c.Metrics.EndpointNormalizer {
+	case "route", "simple":
+	default:
+		invalid("metrics.endpoint_normalizer", "must be route or simple, got %q", c.Metrics.EndpointNormalizer)
+	}
+	if c.Trash.Retention <= 0 {
+		invalid("trash.retention", "must be positive")
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with secrets masked, safe
+// to print or log.
+func (c Config) Redacted() Config {
+	if len(c.Tracing.Headers) > 0 {
+		headers := make(map[string]string, len(c.Tracing.Headers))
+		for k := range c.Tracing.Headers {
+			headers[k] = redacted
+		}
+		c.Tracing.Headers = headers
+	}
+	if u, err := url.Parse(c.Tracing.Endpoint); err == nil && u.User != nil {
+		c.Tracing.Endpoint = u.Redacted()
+	}
+	return c
+}
+
+const redacted = "REDACTED"
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable holding the configuration file,
+// used when the -config flag is not given.
+const FileEnv = "BOOK_SERVICE_CONFIG"
+
+// setting binds a configuration field to its flag and environment variable.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	field func(c *Config) any
+}
+
+var settings = []setting{
+	{"service-name", "OTEL_SERVICE_NAME", "name of the service in traces",
+		func(c *Config) any { return &c.ServiceName }},
+	{"addr", "BOOK_SERVICE_ADDR", "address the HTTP server listens on",
+		func(c *Config) any { return &c.Server.Addr }},
+	{"read-timeout", "BOOK_SERVICE_READ_TIMEOUT", "maximum duration for reading a request",
+		func(c *Config) any { return &c.Server.ReadTimeout }},
+	{"write-timeout", "BOOK_SERVICE_WRITE_TIMEOUT", "maximum duration for handling a request and writing its response",
+		func(c *Config) any { return &c.Server.WriteTimeout }},
+	{"idle-timeout", "BOOK_SERVICE_IDLE_TIMEOUT", "how long idle keep-alive connections are kept open",
+		func(c *Config) any { return &c.Server.IdleTimeout }},
+	{"drain-period", "BOOK_SERVICE_DRAIN_PERIOD", "how long to keep serving, reporting not ready, before shutting down",
+		func(c *Config) any { return &c.Server.DrainPeriod }},
+	{"shutdown-timeout", "BOOK_SERVICE_SHUTDOWN_TIMEOUT", "how long to wait for in-flight requests when shutting down",
+		func(c *Config) any { return &c.Server.ShutdownTimeout }},
+	{"store", "BOOK_SERVICE_STORE", "book storage backend: sqlite or memory",
+		func(c *Config) any { return &c.Store.Type }},
+	{"db-path", "BOOK_SERVICE_DB_PATH", "SQLite database file",
+		func(c *Config) any { return &c.Store.Path }},
+	{"traces-exporter", "OTEL_TRACES_EXPORTER", "trace exporter to use: otlp, stdout or none",
+		func(c *Config) any { return &c.Tracing.Exporter }},
+	{"otlp-endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "base URL of the OTLP/HTTP collector",
+		func(c *Config) any { return &c.Tracing.Endpoint }},
+	{"otlp-headers", "OTEL_EXPORTER_OTLP_HEADERS", "headers sent to the collector, as key=value pairs separated by commas",
+		func(c *Config) any { return &c.Tracing.Headers }},
+	{"metrics-exporter", "OTEL_METRICS_EXPORTER", "metrics exporter to use: prometheus, expvar, otlp, stdout or none",
+		func(c *Config) any { return &c.Metrics.Exporter }},
+	{"metrics-interval", "BOOK_SERVICE_METRICS_INTERVAL", "how often otlp and stdout export metrics",
+		func(c *Config) any { return &c.Metrics.Interval }},
+	{"metrics-span-kinds", "BOOK_SERVICE_METRICS_SPAN_KINDS", "kinds of spans request metrics are reported for, separated by commas",
+		func(c *Config) any { return &c.Metrics.SpanKinds }},
+	{"metrics-max-endpoints", "BOOK_SERVICE_METRICS_MAX_ENDPOINTS", "how many endpoints request metrics tell apart",
+		func(c *Config) any { return &c.Metrics.MaxEndpoints }},
+	{"metrics-max-peers", "BOOK_SERVICE_METRICS_MAX_PEERS", "how many dependencies the metrics of calls tell apart",
+		func(c *Config) any { return &c.Metrics.MaxPeers }},
+	{"metrics-endpoint-normalizer", "BOOK_SERVICE_METRICS_ENDPOINT_NORMALIZER", "how endpoint names are normalized: route or simple",
+		func(c *Config) any { return &c.Metrics.EndpointNormalizer }},
+	{"trash-retention", "BOOK_SERVICE_TRASH_RETENTION", "how long deleted books are kept in the trash",
+		func(c *Config) any { return &c.Trash.Retention }},
+}
+
+// Load registers the configuration flags on fs, parses args with it and
+// returns the validated configuration. Flags take precedence over the
+// environment, read with lookupEnv, which takes precedence over the file
+// given by -config or FileEnv.
+func Load(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	defaults := Default()
+	file := fs.String("config", "", "YAML or JSON configuration file (env "+FileEnv+")")
+	flags := make(map[string]string)
+	for _, s := range settings {
+		usage := fmt.Sprintf("%s (env %s)", s.usage, s.env)
+		if def := format(s.field(&defaults)); def != "" {
+			usage += fmt.Sprintf(" (default %q)", def)
+		}
+		fs.Func(s.flag, usage, func(v string) error {
+			if err := set(s.field(&Config{}), v); err != nil {
+				return err
+			}
+			flags[s.flag] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := defaults
+	if *file == "" {
+		*file, _ = lookupEnv(FileEnv)
+	}
+	if *file != "" {
+		if err := loadFile(&cfg, *file); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", *file, err)
+		}
+	}
+	for _, s := range settings {
+		if v, ok := lookupEnv(s.env); ok && v != "" {
+			if err := set(s.field(&cfg), v); err != nil {
+				return nil, fmt.Errorf("environment variable %s: %w", s.env, err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if v, ok := flags[s.flag]; ok {
+			// already checked while parsing
+			_ = set(s.field(&cfg), v)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return &cfg, nil
+}
+
+// loadFile overlays cfg with the settings of a YAML or JSON file. Unknown
+// settings are rejected to catch typos.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		return dec.Decode(cfg)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// set parses v into the configuration field pointed to by field.
+func set(field any, v string) error {
+	switch f := field.(type) {
+	case *string:
+		*f = v
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return err
+		}
+		*f = n
+	case *Duration:
+		return f.UnmarshalText([]byte(v))
+	case *map[string]string:
+		headers, err := parseHeaders(v)
+		if err != nil {
+			return err
+		}
+		*f = headers
+	case *[]string:
+		*f = parseList(v)
+	default:
+		panic(fmt.Sprintf("config: unsupported setting type %T", field))
+	}
+	return nil
+}
+
+// format renders the value of a configuration field for flag usage.
+func format(field any) string {
+	switch f := field.(type) {
+	case *string:
+		return *f
+	case *int:
+		return strconv.Itoa(*f)
+	case *Duration:
+		text, _ := f.MarshalText()
+		return string(text)
+	case *map[string]string:
+		pairs := make([]string, 0, len(*f))
+		for k, v := range *f {
+			pairs = append(pairs, k+"="+url.QueryEscape(v))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	case *[]string:
+		return strings.Join(*f, ",")
+	}
+	return fmt.Sprint(field)
+}
+
+// parseList reads a list of comma separated values.
+func parseList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseHeaders reads headers in the format of OTEL_EXPORTER_OTLP_HEADERS:
+// comma separated key=value pairs with URL-encoded values.
+func parseHeaders(v string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("header %q must be key=value", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", key, err)
+		}
+		headers[key] = value
+	}
+	return headers, nil
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"sample-app/services"
+)
+
+// ActorHeader names the user making a request. It is recorded in the
+// history of the books the request changes.
+//
+// The service does not authenticate requests, so it trusts the header as
+// sent. It must be deployed behind a proxy that authenticates callers and
+// sets the header to their identity, replacing any value the caller sent;
+// otherwise the actors in the history can be forged.
+const ActorHeader = "X-Actor"
+
+// maxActorLength bounds the actor names stored in the history, in bytes.
+const maxActorLength = 128
+
+// Actor is a middleware that attaches the actor named by ActorHeader to
+// the request context.
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.ToValidUTF8(strings.TrimSpace(r.Header.Get(ActorHeader)), "")
+		if actor != "" {
+			r = r.WithContext(services.WithActor(r.Context(), truncateActor(actor)))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// truncateActor shortens actor to maxActorLength bytes without splitting
+// a character.
+func truncateActor(actor string) string {
+	if len(actor) <= maxActorLength {
+		return actor
+	}
+	cut := maxActorLength
+	for cut > 0 && !utf8.RuneStart(actor[cut]) {
+		cut--
+	}
+	return actor[:cut]
+}
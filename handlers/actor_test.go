@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"sample-app/services"
+)
+
+func TestActor(t *testing.T) {
+	long := strings.Repeat("é", maxActorLength) // 2 bytes per character
+	tests := []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"trimmed", "  alice  ", "alice"},
+		{"missing", "", services.AnonymousActor},
+		{"truncated on a character boundary", "a" + long, "a" + long[:maxActorLength-2]},
+		{"invalid UTF-8 dropped", "bob\xff", "bob"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := Actor(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = services.ActorFromContext(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(ActorHeader, tt.header)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("got actor %q, want %q", got, tt.want)
+			}
+			if len(got) > maxActorLength || !utf8.ValidString(got) {
+				t.Errorf("actor %q is not a valid string of at most %d bytes", got, maxActorLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"sample-app/models"
+	"sample-app/services"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+)
+
+type AuthorHandler struct {
+	authorService *services.AuthorService
+	bookService   *services.BookService
+}
+
+func NewAuthorHandler(authorService *services.AuthorService, bookService *services.BookService) *AuthorHandler {
+	return &AuthorHandler{
+		authorService: authorService,
+		bookService:   bookService,
+	}
+}
+
+// CreateAuthor handles the creation of a new author
+func (h *AuthorHandler) CreateAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "CreateAuthorHandler")
+	defer span.End()
+
+	var author models.Author
+	if !decodeJSON(w, r, &author) {
+		return
+	}
+
+	if err := h.authorService.CreateAuthor(ctx, &author); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/authors/"+strconv.FormatUint(uint64(author.ID), 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(author)
+}
+
+// GetAuthor handles retrieving an author by ID
+func (h *AuthorHandler) GetAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "GetAuthorHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The author ID must be a positive integer.")
+		return
+	}
+
+	author, err := h.authorService.GetAuthor(ctx, uint(id))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(author)
+}
+
+// ListAuthors handles retrieving a page of authors
+func (h *AuthorHandler) ListAuthors(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "ListAuthorsHandler")
+	defer span.End()
+
+	opts, err := parseAuthorListOptions(r.URL.Query())
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid query: "+err.Error()+".")
+		return
+	}
+
+	list, err := h.authorService.ListAuthors(ctx, opts)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := authorListResponse{Items: list.Authors}
+	if list.HasMore {
+		query := r.URL.Query()
+		query.Set("offset", strconv.Itoa(opts.Offset+len(list.Authors)))
+		next := (&url.URL{Path: r.URL.Path, RawQuery: query.Encode()}).String()
+		resp.Links = map[string]string{"next": next}
+		w.Header().Set("Link", "<"+next+`>; rel="next"`)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// UpdateAuthor handles renaming an author
+func (h *AuthorHandler) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "UpdateAuthorHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The author ID must be a positive integer.")
+		return
+	}
+
+	var author models.Author
+	if !decodeJSON(w, r, &author) {
+		return
+	}
+	if author.ID != 0 && author.ID != uint(id) {
+		writeProblem(w, r, http.StatusBadRequest, "The author ID in the body does not match the URL.")
+		return
+	}
+	author.ID = uint(id)
+
+	if err := h.authorService.UpdateAuthor(ctx, &author); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(author)
+}
+
+// DeleteAuthor handles removing an author who is not credited for any book
+func (h *AuthorHandler) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "DeleteAuthorHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The author ID must be a positive integer.")
+		return
+	}
+
+	if err := h.authorService.DeleteAuthor(ctx, uint(id)); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAuthorBooks handles retrieving a page of the books of an author
+func (h *AuthorHandler) ListAuthorBooks(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "ListAuthorBooksHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The author ID must be a positive integer.")
+		return
+	}
+
+	opts, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid query: "+err.Error()+".")
+		return
+	}
+	opts.AuthorID = uint(id)
+
+	if _, err := h.authorService.GetAuthor(ctx, opts.AuthorID); err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	writeBookList(ctx, w, r, h.bookService, opts)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"sample-app/pkg/jsonpatch"
+	"sample-app/services"
+)
+
+// maxBodyBytes limits the size of request payloads.
+const maxBodyBytes = 64 << 10
+
+// decodeJSON strictly decodes a single JSON document from the request body
+// into v, rejecting unknown fields and oversized bodies. If the body is not
+// acceptable it responds with a problem and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(v)
+	if err == nil && dec.More() {
+		err = errors.New("body must contain a single JSON document")
+	}
+	if err == nil {
+		return true
+	}
+	writeBodyProblem(w, r, err)
+	return false
+}
+
+// readPatch reads a JSON Merge Patch or JSON Patch document from the request
+// body, depending on its Content-Type. If the body is not acceptable it
+// responds with a problem and returns false.
+func readPatch(w http.ResponseWriter, r *http.Request) (services.Patch, bool) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != jsonpatch.MergePatchType && mediaType != jsonpatch.JSONPatchType {
+		w.Header().Set("Accept-Patch", jsonpatch.MergePatchType+", "+jsonpatch.JSONPatchType)
+		writeProblem(w, r, http.StatusUnsupportedMediaType,
+			"Patches must be sent as "+jsonpatch.MergePatchType+" or "+jsonpatch.JSONPatchType+".")
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeBodyProblem(w, r, err)
+		return nil, false
+	}
+	var patch services.Patch
+	if mediaType == jsonpatch.MergePatchType {
+		patch, err = jsonpatch.ParseMergePatch(body)
+	} else {
+		patch, err = jsonpatch.ParsePatch(body)
+	}
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid request body: "+err.Error()+".")
+		return nil, false
+	}
+	return patch, true
+}
+
+// writeBodyProblem responds with a problem describing why the request body
+// could not be read or decoded.
+func writeBodyProblem(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+	)
+	status, detail := http.StatusBadRequest, err.Error()
+	switch {
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+		detail = fmt.Sprintf("body must not be larger than %d bytes", maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		detail = "body must not be empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		detail = "body contains malformed JSON"
+	case errors.As(err, &syntaxErr):
+		detail = fmt.Sprintf("body contains malformed JSON at offset %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		detail = fmt.Sprintf("field %q must be of type %s", typeErr.Field, typeErr.Type)
+	case strings.HasPrefix(detail, "json: unknown field "):
+		detail = "body contains unknown field " + strings.TrimPrefix(detail, "json: unknown field ")
+	}
+	writeProblem(w, r, status, "Invalid request body: "+detail+".")
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCreateBookRejectsInvalidBodies(t *testing.T) {
+	tests := []struct {
+		name, body string
+		status     int
+		detail     string
+	}{
+		{"empty", " ", http.StatusBadRequest, "Invalid request body: body must not be empty."},
+		{"malformed", `{"title":"Dune",}`, http.StatusBadRequest, "Invalid request body: body contains malformed JSON at offset 17."},
+		{"truncated", `{"title":"Dune"`, http.StatusBadRequest, "Invalid request body: body contains malformed JSON."},
+		{"unknown field", `{"title":"Dune","author":"X","price":3}`, http.StatusBadRequest,
+			`Invalid request body: body contains unknown field "price".`},
+		{"wrong type", `{"title":42,"author":"X"}`, http.StatusBadRequest,
+			`Invalid request body: field "title" must be of type string.`},
+		{"two documents", `{"title":"Dune","author":"X"} {}`, http.StatusBadRequest,
+			"Invalid request body: body must contain a single JSON document."},
+		{"too large", `{"title":"` + strings.Repeat("a", maxBodyBytes) + `","author":"X"}`,
+			http.StatusRequestEntityTooLarge, "Invalid request body: body must not be larger than 65536 bytes."},
+	}
+	r := newTestRouter()
+	for _, tt := range tests {
+		rec := serve(r, "POST", "/books", tt.body)
+		if rec.Code != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.name, rec.Code, tt.status)
+			continue
+		}
+		if p := decodeProblem(t, rec); p.Detail != tt.detail {
+			t.Errorf("%s: got detail %q, want %q", tt.name, p.Detail, tt.detail)
+		}
+	}
+}
+
+func TestCreateBookReportsInvalidFields(t *testing.T) {
+	rec := serve(newTestRouter(), "POST", "/books", `{"title":" ","author":"X"}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("got %d %s, want 422", rec.Code, rec.Body)
+	}
+	p := decodeProblem(t, rec)
+	if p.Type != "/problems/validation-failed" || len(p.Errors) != 1 ||
+		p.Errors[0].Field != "title" || p.Errors[0].Message != "is required" {
+		t.Errorf("got %+v, want the missing title reported", p)
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"sample-app/models"
+	"sample-app/pkg/jsonpatch"
+	"sample-app/services"
+)
+
+// statusForError maps errors returned by the services to HTTP status codes.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, services.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// detailForError describes a service error without leaking storage internals.
+func detailForError(err error) string {
+	var serr *services.Error
+	if !errors.As(err, &serr) || serr.Kind == nil {
+		return "An unexpected error occurred."
+	}
+	switch serr.Kind {
+	case services.ErrUnavailable:
+		return "The service is temporarily unavailable, please retry later."
+	case services.ErrValidation:
+		return "Cannot " + serr.Op + ": " + serr.Err.Error() + "."
+	case services.ErrConflict:
+		if errors.Is(serr.Err, jsonpatch.ErrTestFailed) ||
+			errors.Is(serr.Err, models.ErrAuthorInUse) ||
+			errors.Is(serr.Err, models.ErrDuplicateISBN) {
+			return "Cannot " + serr.Op + ": " + serr.Err.Error() + "."
+		}
+	}
+	return "Cannot " + serr.Op + ": " + serr.Kind.Error() + "."
+}
+
+// writeServiceError responds with the problem matching a service error.
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	p := newProblem(r, statusForError(err), detailForError(err))
+	var verr models.ValidationError
+	if errors.As(err, &verr) {
+		p.Errors = verr
+	}
+	writeProblemDocument(w, p)
+}
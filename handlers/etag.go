@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sample-app/models"
+)
+
+var errMultipleETags = errors.New("If-Match must contain a single entity tag")
+
+// etag returns the strong entity tag of a book, derived from its version.
+func etag(book *models.Book) string {
+	return `"` + strconv.FormatUint(uint64(book.Version), 10) + `"`
+}
+
+// weakETag returns a weak entity tag for an arbitrary response body.
+func weakETag(body []byte) string {
+	h := fnv.New64a()
+	h.Write(body)
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+// ifMatchVersion returns the book version required by the If-Match header,
+// or 0 if the header is absent or "*". Entity tags that cannot belong to a
+// book, such as weak ones, yield a version that never matches.
+func ifMatchVersion(r *http.Request) (uint, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, errMultipleETags
+	}
+	version, err := strconv.ParseUint(strings.Trim(header, `"`), 10, 32)
+	if err != nil || !strings.HasPrefix(header, `"`) || version == 0 {
+		return ^uint(0), nil
+	}
+	return uint(version), nil
+}
+
+// ifNoneMatch reports whether the If-None-Match header matches the entity
+// tag, using the weak comparison required for GET requests.
+func ifNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified responds with 304 if the request's If-None-Match header
+// matches the entity tag, and reports whether it did.
+func writeNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if !ifNoneMatch(r, etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
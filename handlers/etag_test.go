@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestListItemVersionsMatchETags(t *testing.T) {
+	r := newTestRouter()
+	serve(r, "POST", "/books", `{"title":"Dune","author":"Frank Herbert"}`)
+	serve(r, "PUT", "/books/1", `{"title":"Dune Messiah","author":"Frank Herbert"}`)
+
+	rec := serve(r, "GET", "/books", "")
+	var page bookListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	listETag := rec.Header().Get("ETag")
+	itemETag := `"` + strconv.FormatUint(uint64(page.Items[0].Version), 10) + `"`
+	if got := serve(r, "GET", "/books/1", "").Header().Get("ETag"); got != itemETag || itemETag != `"2"` {
+		t.Fatalf("got ETag %s for the book, want %s from its list item version", got, itemETag)
+	}
+
+	req := httptest.NewRequest("GET", "/books", nil)
+	req.Header.Set("If-None-Match", listETag)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("list with If-None-Match: got %d, want 304", rec.Code)
+	}
+
+	for _, tt := range []struct {
+		ifMatch string
+		code    int
+	}{
+		{`"1"`, http.StatusPreconditionFailed},
+		{listETag, http.StatusPreconditionFailed},
+		{itemETag, http.StatusOK},
+	} {
+		req := httptest.NewRequest("PUT", "/books/1", strings.NewReader(`{"title":"Children of Dune","author":"Frank Herbert"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", tt.ifMatch)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("If-Match %s: got %d %s, want %d", tt.ifMatch, rec.Code, rec.Body, tt.code)
+		}
+	}
+}
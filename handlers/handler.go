@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -29,16 +30,17 @@ func (h *BookHandler) CreateBook(w http.ResponseWriter, r *http.Request) {
 	defer span.End()
 
 	var book models.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &book) {
 		return
 	}
 
 	if err := h.bookService.CreateBook(ctx, &book); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Location", "/books/"+strconv.FormatUint(uint64(book.ID), 10))
+	w.Header().Set("ETag", etag(&book))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(book)
@@ -52,13 +54,22 @@ func (h *BookHandler) GetBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseUint(vars["id"], 10, 32)
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "The book ID must be a positive integer.")
 		return
 	}
 
-	book, err := h.bookService.GetBook(ctx, uint(id))
+	includeDeleted, err := boolParam(r.URL.Query(), "include_deleted")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeProblem(w, r, http.StatusBadRequest, "Invalid query: "+err.Error()+".")
+		return
+	}
+
+	book, err := h.bookService.GetBook(ctx, uint(id), includeDeleted)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if writeNotModified(w, r, etag(book)) {
 		return
 	}
 
@@ -66,22 +77,106 @@ func (h *BookHandler) GetBook(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(book)
 }
 
-// ListBooks handles retrieving all books
+// ListBooks handles retrieving a page of books
 func (h *BookHandler) ListBooks(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "ListBooksHandler")
 	defer span.End()
 
-	books, err := h.bookService.ListBooks(ctx)
+	opts, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid query: "+err.Error()+".")
+		return
+	}
+	writeBookList(ctx, w, r, h.bookService, opts)
+}
+
+// ListTrash handles retrieving a page of deleted books
+func (h *BookHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "ListTrashHandler")
+	defer span.End()
+
+	opts, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid query: "+err.Error()+".")
+		return
+	}
+	opts.OnlyDeleted = true
+	writeBookList(ctx, w, r, h.bookService, opts)
+}
+
+func writeBookList(ctx context.Context, w http.ResponseWriter, r *http.Request, books *services.BookService, opts services.ListOptions) {
+	list, err := books.ListBooks(ctx, opts)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	resp := bookListResponse{
+		Items:      list.Books,
+		Total:      list.Total,
+		NextCursor: list.NextCursor,
+	}
+	if next := nextPageURL(r, opts, list); next != "" {
+		resp.Links = map[string]string{"next": next}
+		w.Header().Set("Link", "<"+next+`>; rel="next"`)
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+	if writeNotModified(w, r, weakETag(body)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+// PurgeTrash handles permanently removing books deleted longer ago than older_than
+func (h *BookHandler) PurgeTrash(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "PurgeTrashHandler")
+	defer span.End()
+
+	retention, err := durationParam(r.URL.Query(), "older_than")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusBadRequest, "Invalid query: "+err.Error()+".")
+		return
+	}
+
+	purged, err := h.bookService.PurgeDeletedBooks(ctx, retention)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(purgeResponse{Purged: purged})
+}
+
+// SearchBooks handles full-text search over book titles and authors
+func (h *BookHandler) SearchBooks(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "SearchBooksHandler")
+	defer span.End()
+
+	limit, err := intParam(r.URL.Query(), "limit")
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "Invalid query: "+err.Error()+".")
+		return
+	}
+
+	results, err := h.bookService.SearchBooks(ctx, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(books)
+	json.NewEncoder(w).Encode(searchResponse{Items: results})
 }
 
-// UpdateBook handles updating a book
+// UpdateBook handles replacing a book, all fields must be provided
 func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "UpdateBookHandler")
 	defer span.End()
@@ -89,27 +184,69 @@ func (h *BookHandler) UpdateBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseUint(vars["id"], 10, 32)
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "The book ID must be a positive integer.")
 		return
 	}
 
 	var book models.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &book) {
+		return
+	}
+	if book.ID != 0 && book.ID != uint(id) {
+		writeProblem(w, r, http.StatusBadRequest, "The book ID in the body does not match the URL.")
 		return
 	}
 	book.ID = uint(id)
+	if book.Version, err = ifMatchVersion(r); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error()+".")
+		return
+	}
 
 	if err := h.bookService.UpdateBook(ctx, &book); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(&book))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(book)
 }
 
-// DeleteBook handles deleting a book
+// PatchBook handles partial updates of a book using JSON Merge Patch or JSON Patch
+func (h *BookHandler) PatchBook(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "PatchBookHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The book ID must be a positive integer.")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error()+".")
+		return
+	}
+
+	patch, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := h.bookService.PatchBook(ctx, uint(id), version, patch)
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(book))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(book)
+}
+
+// DeleteBook handles moving a book to the trash
 func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "DeleteBookHandler")
 	defer span.End()
@@ -117,14 +254,107 @@ func (h *BookHandler) DeleteBook(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseUint(vars["id"], 10, 32)
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "The book ID must be a positive integer.")
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, err.Error()+".")
 		return
 	}
 
-	if err := h.bookService.DeleteBook(ctx, uint(id)); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.bookService.DeleteBook(ctx, uint(id), version); err != nil {
+		writeServiceError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// RestoreBook handles bringing a deleted book back from the trash
+func (h *BookHandler) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "RestoreBookHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The book ID must be a positive integer.")
+		return
+	}
+
+	book, err := h.bookService.RestoreBook(ctx, uint(id))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(book))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(book)
+}
+
+// ListBookHistory handles retrieving every recorded change of a book
+func (h *BookHandler) ListBookHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "ListBookHistoryHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The book ID must be a positive integer.")
+		return
+	}
+
+	revs, err := h.bookService.ListBookHistory(ctx, uint(id))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(historyResponse{Items: revs})
+}
+
+// GetBookRevision handles retrieving a book as of a given revision
+func (h *BookHandler) GetBookRevision(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "GetBookRevisionHandler")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The book ID must be a positive integer.")
+		return
+	}
+	revision, err := strconv.ParseUint(vars["revision"], 10, 32)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "The revision must be a positive integer.")
+		return
+	}
+
+	rev, err := h.bookService.GetBookRevision(ctx, uint(id), uint(revision))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rev.After)
+}
+
+// ListTags handles retrieving the genres and tags of the catalog
+func (h *BookHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http-handler").Start(r.Context(), "ListTagsHandler")
+	defer span.End()
+
+	tags, err := h.bookService.ListTags(ctx, r.URL.Query().Get("kind"))
+	if err != nil {
+		writeServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tagListResponse{Items: tags})
+}
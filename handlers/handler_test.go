@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sample-app/models"
+	"sample-app/services"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestRouter routes the book endpoints to a BookHandler backed by an
+// empty in-memory store.
+func newTestRouter() *mux.Router {
+	h := NewBookHandler(services.NewBookService(models.NewMemoryStore(), 0))
+	r := mux.NewRouter()
+	r.HandleFunc("/books", h.CreateBook).Methods("POST")
+	r.HandleFunc("/books", h.ListBooks).Methods("GET")
+	r.HandleFunc("/books/search", h.SearchBooks).Methods("GET")
+	r.HandleFunc("/books/{id}", h.GetBook).Methods("GET")
+	r.HandleFunc("/books/{id}", h.UpdateBook).Methods("PUT")
+	r.HandleFunc("/books/{id}", h.DeleteBook).Methods("DELETE")
+	r.HandleFunc("/tags", h.ListTags).Methods("GET")
+	return r
+}
+
+// serve sends a request with the given body, if any, to h.
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != "" {
+		req = httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBookHandlerCRUD(t *testing.T) {
+	r := newTestRouter()
+
+	rec := serve(r, "POST", "/books", `{"title":"Dune","author":"Frank Herbert"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: got %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(r, "GET", "/books/1", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"Dune"`) {
+		t.Errorf("get: got %d %s", rec.Code, rec.Body)
+	}
+	rec = serve(r, "PUT", "/books/1", `{"title":"Dune Messiah","author":"Frank Herbert"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"Dune Messiah"`) {
+		t.Errorf("update: got %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(r, "DELETE", "/books/1", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete: got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestBookHandlerErrorStatus(t *testing.T) {
+	r := newTestRouter()
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{"GET", "/books/42", "", http.StatusNotFound},
+		{"PUT", "/books/42", `{"title":"Dune","author":"Frank Herbert"}`, http.StatusNotFound},
+		{"DELETE", "/books/42", "", http.StatusNotFound},
+		{"GET", "/books/abc", "", http.StatusBadRequest},
+		{"POST", "/books", `{"title":`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := serve(r, tt.method, tt.target, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestListBooksPages(t *testing.T) {
+	r := newTestRouter()
+	for _, title := range []string{"A", "B", "C"} {
+		if rec := serve(r, "POST", "/books", `{"title":"`+title+`","author":"X"}`); rec.Code != http.StatusCreated {
+			t.Fatalf("create: got %d %s", rec.Code, rec.Body)
+		}
+	}
+
+	rec := serve(r, "GET", "/books?limit=2&sort=title&include_total=true", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	var page bookListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 2 || page.Total == nil || *page.Total != 3 || page.NextCursor == "" {
+		t.Fatalf("got %s, want 2 of 3 books and a cursor", rec.Body)
+	}
+	if link := rec.Header().Get("Link"); link != "<"+page.Links["next"]+`>; rel="next"` {
+		t.Errorf("got Link %q, want the next link %q", link, page.Links["next"])
+	}
+
+	rec = serve(r, "GET", page.Links["next"], "")
+	page = bookListResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Title != "C" || page.NextCursor != "" {
+		t.Errorf("got next page %s, want the last book only", rec.Body)
+	}
+
+	rec = serve(r, "GET", "/books?offset=1&limit=1", "")
+	page = bookListResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Links["next"] != "/books?limit=1&offset=2" {
+		t.Errorf("got next link %q, want the next offset", page.Links["next"])
+	}
+}
+
+func TestListBooksRejectsInvalidQueries(t *testing.T) {
+	r := newTestRouter()
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"limit=ten", http.StatusBadRequest},
+		{"include_total=maybe", http.StatusBadRequest},
+		{"limit=1000", http.StatusUnprocessableEntity},
+		{"sort=price", http.StatusUnprocessableEntity},
+		{"offset=-1", http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		if rec := serve(r, "GET", "/books?"+tt.query, ""); rec.Code != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.query, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestSearchBooks(t *testing.T) {
+	r := newTestRouter()
+	serve(r, "POST", "/books", `{"title":"Children of Dune","author":"Frank Herbert"}`)
+	serve(r, "POST", "/books", `{"title":"Emma","author":"Jane Austen"}`)
+
+	rec := serve(r, "GET", "/books/search?q=dune", "")
+	var results searchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("got %d %s: %v", rec.Code, rec.Body, err)
+	}
+	if len(results.Items) != 1 || results.Items[0].Highlights.Title != "Children of <mark>Dune</mark>" {
+		t.Errorf("got %s, want the highlighted match", rec.Body)
+	}
+
+	if rec := serve(r, "GET", "/books/search?q=", ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("empty query: got %d, want 422", rec.Code)
+	}
+	if rec := serve(r, "GET", "/books/search?q=dune&limit=x", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid limit: got %d, want 400", rec.Code)
+	}
+}
+
+func TestListTagsAndFilterByGenre(t *testing.T) {
+	r := newTestRouter()
+	for _, body := range []string{
+		`{"title":"Dune","author":"Frank Herbert","genres":["Science Fiction"]}`,
+		`{"title":"Emma","author":"Jane Austen","genres":["Romance"],"tags":["classic"]}`,
+	} {
+		if rec := serve(r, "POST", "/books", body); rec.Code != http.StatusCreated {
+			t.Fatalf("create: got %d %s", rec.Code, rec.Body)
+		}
+	}
+
+	rec := serve(r, "GET", "/tags?kind=genre", "")
+	var tags tagListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil {
+		t.Fatal(err)
+	}
+	if len(tags.Items) != 2 || tags.Items[0].Slug != "romance" || tags.Items[1].Slug != "science-fiction" {
+		t.Errorf("got %s, want both genres", rec.Body)
+	}
+	if rec := serve(r, "GET", "/tags?kind=shelf", ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("unknown kind: got %d %s", rec.Code, rec.Body)
+	}
+
+	rec = serve(r, "GET", "/books?genre=science-fiction", "")
+	var page bookListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Title != "Dune" {
+		t.Errorf("got %s, want Dune only", rec.Body)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheckTimeout bounds each dependency check of a probe.
+const HealthCheckTimeout = 2 * time.Second
+
+// HealthCheck checks a dependency the service needs to serve requests.
+type HealthCheck struct {
+	Name string
+	// Check returns an error if the dependency is unusable.
+	Check func(ctx context.Context) error
+}
+
+// Health serves the liveness, readiness and health endpoints, which report
+// whether the service should receive traffic.
+type Health struct {
+	checks   []HealthCheck
+	draining atomic.Bool
+}
+
+// NewHealth creates a Health for a service that is ready as long as checks
+// pass.
+func NewHealth(checks ...HealthCheck) *Health {
+	return &Health{checks: checks}
+}
+
+// Drain marks the service as shutting down, so that it stops being
+// reported as ready while in-flight requests complete.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Draining reports whether Drain has been called.
+func (h *Health) Draining() bool {
+	return h.draining.Load()
+}
+
+// Probe statuses
+const (
+	statusOK          = "ok"
+	statusReady       = "ready"
+	statusDraining    = "draining"
+	statusUnavailable = "unavailable"
+	statusFailed      = "failed"
+)
+
+type probeResponse struct {
+	Status string `json:"status"`
+}
+
+type healthResponse struct {
+	Status string              `json:"status"`
+	Checks []healthCheckResult `json:"checks"`
+}
+
+type healthCheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// LatencyMS is how long the check took, in milliseconds.
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Live handles liveness probes. It responds 200 OK as long as the process
+// serves requests.
+func (h *Health) Live(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, probeResponse{Status: statusOK})
+}
+
+// Ready handles readiness probes. It responds 503 Service Unavailable once
+// the service is draining or while a dependency check fails.
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.Draining() {
+		writeProbe(w, http.StatusServiceUnavailable, probeResponse{Status: statusDraining})
+		return
+	}
+	for _, result := range h.run(r.Context()) {
+		if result.Status != statusOK {
+			writeProbe(w, http.StatusServiceUnavailable, probeResponse{Status: statusUnavailable})
+			return
+		}
+	}
+	writeProbe(w, http.StatusOK, probeResponse{Status: statusReady})
+}
+
+// Detailed reports the status and latency of every dependency. Like Ready,
+// it responds 503 Service Unavailable unless the service is ready.
+func (h *Health) Detailed(w http.ResponseWriter, r *http.Request) {
+	resp := healthResponse{Status: statusOK, Checks: h.run(r.Context())}
+	for _, result := range resp.Checks {
+		if result.Status != statusOK {
+			resp.Status = statusUnavailable
+		}
+	}
+	if h.Draining() {
+		resp.Status = statusDraining
+	}
+
+	status := http.StatusOK
+	if resp.Status != statusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeProbe(w, status, resp)
+}
+
+// run performs the checks concurrently and returns their results in the
+// order the checks were registered.
+func (h *Health) run(ctx context.Context) []healthCheckResult {
+	results := make([]healthCheckResult, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Check(ctx)
+			results[i] = healthCheckResult{
+				Name:      check.Name,
+				Status:    statusOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = statusFailed
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func writeProbe(w http.ResponseWriter, status int, resp any) {
+	w.Header().Set("Content-Type", "application/json")
+	// probes must observe the current state
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func passing(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func TestHealthProbes(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []HealthCheck
+		drain  bool
+		code   int
+		ready  string
+		health string
+	}{
+		{"no checks", nil, false, http.StatusOK, statusReady, statusOK},
+		{"passing", []HealthCheck{{"database", passing}}, false, http.StatusOK, statusReady, statusOK},
+		{"failing", []HealthCheck{{"database", passing}, {"migrations", failing}}, false,
+			http.StatusServiceUnavailable, statusUnavailable, statusUnavailable},
+		{"draining", []HealthCheck{{"database", passing}}, true, http.StatusServiceUnavailable, statusDraining, statusDraining},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealth(tt.checks...)
+			if tt.drain {
+				h.Drain()
+			}
+
+			rec := serve(http.HandlerFunc(h.Live), "GET", "/healthz", "")
+			if rec.Code != http.StatusOK || rec.Body.String() != `{"status":"ok"}`+"\n" {
+				t.Errorf("live: got %d %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("got Cache-Control %q, want no-store", got)
+			}
+
+			rec = serve(http.HandlerFunc(h.Ready), "GET", "/readyz", "")
+			var probe probeResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &probe); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.code || probe.Status != tt.ready {
+				t.Errorf("ready: got %d %s, want %d %s", rec.Code, rec.Body, tt.code, tt.ready)
+			}
+
+			rec = serve(http.HandlerFunc(h.Detailed), "GET", "/health", "")
+			var health healthResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.code || health.Status != tt.health || len(health.Checks) != len(tt.checks) {
+				t.Errorf("health: got %d %s, want %d %s", rec.Code, rec.Body, tt.code, tt.health)
+			}
+		})
+	}
+}
+
+func TestHealthDetailsChecks(t *testing.T) {
+	h := NewHealth(HealthCheck{"database", passing}, HealthCheck{"migrations", failing})
+	rec := serve(http.HandlerFunc(h.Detailed), "GET", "/health", "")
+
+	var health healthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	want := []healthCheckResult{
+		{Name: "database", Status: statusOK},
+		{Name: "migrations", Status: statusFailed, Error: "connection refused"},
+	}
+	for i := range health.Checks {
+		health.Checks[i].LatencyMS = 0
+	}
+	if len(health.Checks) != 2 || health.Checks[0] != want[0] || health.Checks[1] != want[1] {
+		t.Errorf("got checks %+v, want %+v in registration order", health.Checks, want)
+	}
+}
+
+func TestHealthChecksHaveTimeout(t *testing.T) {
+	h := NewHealth(HealthCheck{"slow", func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > HealthCheckTimeout {
+			t.Errorf("got deadline %v, %v; want one within %v", deadline, ok, HealthCheckTimeout)
+		}
+		return nil
+	}})
+	serve(http.HandlerFunc(h.Ready), "GET", "/readyz", "")
+
+	h = NewHealth(HealthCheck{"stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results := h.run(ctx)
+	if results[0].Status != statusFailed || results[0].Error != context.Canceled.Error() {
+		t.Errorf("got %+v, want the check to stop with its context", results[0])
+	}
+}
+
+func TestHealthRunsChecksConcurrently(t *testing.T) {
+	// each check waits for the other to start, so they only pass when run
+	// concurrently
+	started := make(chan struct{}, 2)
+	check := func(ctx context.Context) error {
+		started <- struct{}{}
+		for {
+			if len(started) == 2 {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+	h := NewHealth(HealthCheck{"a", check}, HealthCheck{"b", check})
+	for _, result := range h.run(context.Background()) {
+		if result.Status != statusOK {
+			t.Errorf("got %+v, want the checks to run concurrently", result)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sample-app/models"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	TraceID  string `json:"trace_id,omitempty"`
+
+	// Errors lists the invalid fields of a rejected payload.
+	Errors []models.FieldError `json:"errors,omitempty"`
+}
+
+// problemTypes maps status codes to the problem type URIs clients can switch on.
+var problemTypes = map[int]string{
+	http.StatusBadRequest:            "/problems/bad-request",
+	http.StatusNotFound:              "/problems/not-found",
+	http.StatusConflict:              "/problems/conflict",
+	http.StatusPreconditionFailed:    "/problems/precondition-failed",
+	http.StatusRequestEntityTooLarge: "/problems/payload-too-large",
+	http.StatusUnsupportedMediaType:  "/problems/unsupported-media-type",
+	http.StatusUnprocessableEntity:   "/problems/validation-failed",
+	http.StatusInternalServerError:   "/problems/internal-error",
+	http.StatusServiceUnavailable:    "/problems/unavailable",
+}
+
+// newProblem creates a problem for the given request and status code,
+// carrying the trace ID of the request span.
+func newProblem(r *http.Request, status int, detail string) *Problem {
+	problemType, ok := problemTypes[status]
+	if !ok {
+		problemType = "about:blank"
+	}
+	p := &Problem{
+		Type:     problemType,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.RequestURI(),
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		p.TraceID = sc.TraceID().String()
+	}
+	return p
+}
+
+// writeProblem responds with an application/problem+json document.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	writeProblemDocument(w, newProblem(r, status, detail))
+}
+
+func writeProblemDocument(w http.ResponseWriter, p *Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"sample-app/services"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("got content type %q, want %s", ct, problemContentType)
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("invalid problem %s: %v", rec.Body, err)
+	}
+	return p
+}
+
+func TestNotFoundProblem(t *testing.T) {
+	rec := serve(newTestRouter(), "GET", "/books/42?verbose=1", "")
+	p := decodeProblem(t, rec)
+	want := Problem{
+		Type:     "/problems/not-found",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "Cannot get book 42: not found.",
+		Instance: "/books/42?verbose=1",
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+}
+
+func TestServiceErrorDetailsHideInternals(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		detail string
+	}{
+		{&services.Error{Op: "create book", Kind: services.ErrUnavailable, Err: errors.New("database is locked")},
+			http.StatusServiceUnavailable, "The service is temporarily unavailable, please retry later."},
+		{&services.Error{Op: "create book", Err: errors.New("disk I/O error")},
+			http.StatusInternalServerError, "An unexpected error occurred."},
+		{errors.New("boom"), http.StatusInternalServerError, "An unexpected error occurred."},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeServiceError(rec, httptest.NewRequest("POST", "/books", nil), tt.err)
+		p := decodeProblem(t, rec)
+		if p.Status != tt.status || rec.Code != tt.status || p.Detail != tt.detail {
+			t.Errorf("%v: got %d %+v, want %d %q", tt.err, rec.Code, p, tt.status, tt.detail)
+		}
+	}
+}
+
+func TestProblemCarriesTraceID(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	rec := httptest.NewRecorder()
+	writeProblem(rec, httptest.NewRequest("GET", "/books/x", nil).WithContext(ctx), http.StatusBadRequest, "bad")
+	if p := decodeProblem(t, rec); p.TraceID != span.SpanContext().TraceID().String() {
+		t.Errorf("got trace ID %q, want %s", p.TraceID, span.SpanContext().TraceID())
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"sample-app/models"
+	"sample-app/services"
+)
+
+// bookListResponse is the body of GET /books. Its weak ETag covers the
+// whole page; the entity tag of each item, as expected by If-Match, is its
+// version in double quotes, so items need not be fetched again to be
+// updated.
+type bookListResponse struct {
+	Items      []models.Book     `json:"items"`
+	Total      *int64            `json:"total,omitempty"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Links      map[string]string `json:"links,omitempty"`
+}
+
+// authorListResponse is the body of GET /authors.
+type authorListResponse struct {
+	Items []models.Author   `json:"items"`
+	Links map[string]string `json:"links,omitempty"`
+}
+
+// tagListResponse is the body of GET /tags.
+type tagListResponse struct {
+	Items []models.Tag `json:"items"`
+}
+
+// searchResponse is the body of GET /books/search.
+type searchResponse struct {
+	Items []models.SearchResult `json:"items"`
+}
+
+// historyResponse is the body of GET /books/{id}/history.
+type historyResponse struct {
+	Items []models.BookRevision `json:"items"`
+}
+
+// purgeResponse is the body of DELETE /books/trash.
+type purgeResponse struct {
+	Purged int64 `json:"purged"`
+}
+
+// parseListOptions reads the pagination, sorting and filtering query parameters.
+func parseListOptions(query url.Values) (services.ListOptions, error) {
+	opts := services.ListOptions{
+		Cursor:       query.Get("cursor"),
+		Sort:         query.Get("sort"),
+		Title:        query.Get("title"),
+		TitlePrefix:  query.Get("title_prefix"),
+		Author:       query.Get("author"),
+		AuthorPrefix: query.Get("author_prefix"),
+		Genre:        query.Get("genre"),
+		Tag:          query.Get("tag"),
+	}
+	var err error
+	if opts.Limit, err = intParam(query, "limit"); err != nil {
+		return opts, err
+	}
+	if opts.Offset, err = intParam(query, "offset"); err != nil {
+		return opts, err
+	}
+	if opts.IncludeTotal, err = boolParam(query, "include_total"); err != nil {
+		return opts, err
+	}
+	if opts.IncludeDeleted, err = boolParam(query, "include_deleted"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+func boolParam(query url.Values, name string) (bool, error) {
+	v := query.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean", name)
+	}
+	return b, nil
+}
+
+func durationParam(query url.Values, name string) (time.Duration, error) {
+	v := query.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration such as 720h", name)
+	}
+	return d, nil
+}
+
+func intParam(query url.Values, name string) (int, error) {
+	v := query.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	return n, nil
+}
+
+// parseAuthorListOptions reads the pagination and filtering query parameters.
+func parseAuthorListOptions(query url.Values) (services.AuthorListOptions, error) {
+	opts := services.AuthorListOptions{NamePrefix: query.Get("name_prefix")}
+	var err error
+	if opts.Limit, err = intParam(query, "limit"); err != nil {
+		return opts, err
+	}
+	if opts.Offset, err = intParam(query, "offset"); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// nextPageURL builds the link to the page following list. Offset based
+// listings continue with an offset, all others with the cursor.
+func nextPageURL(r *http.Request, opts services.ListOptions, list *services.BookList) string {
+	if list.NextCursor == "" {
+		return ""
+	}
+	query := r.URL.Query()
+	if query.Has("offset") {
+		query.Set("offset", strconv.Itoa(opts.Offset+len(list.Books)))
+	} else {
+		query.Set("cursor", list.NextCursor)
+	}
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return next.String()
+}
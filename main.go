@@ -1,71 +1,64 @@
-// main.go
 package main
 
 import (
 	"context"
-	"log"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
 
-	"sample-app/handlers"
+	"sample-app/config"
+	"sample-app/pkg/log"
 
-	"sample-app/models"
-	"sample-app/services"
-
-	"github.com/gorilla/mux"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 )
 
-func initTracer() func() {
-	exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint("http://localhost:14268/api/traces")))
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [serve | migrate up|down [n]|status|unlock | config print]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:], os.LookupEnv)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
-		sdktrace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceNameKey.String("book-service"),
-		)),
-	)
-	otel.SetTracerProvider(tp)
+	zapLogger, err := zap.NewProduction()
+	if err != nil {
+		panic(err)
+	}
+	defer zapLogger.Sync()
+	logger := log.NewFactory(zapLogger)
 
-	return func() {
-		if err := tp.Shutdown(context.Background()); err != nil {
-			log.Printf("Error shutting down tracer provider: %v", err)
+	switch flag.Arg(0) {
+	case "", "serve":
+		if err := serve(logger, cfg); err != nil {
+			logger.Bg().Fatal("Server failed", zap.Error(err))
+		}
+	case "migrate":
+		err := runMigrate(context.Background(), os.Stdout, cfg, flag.Args()[1:])
+		if errors.Is(err, errMigrateUsage) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if err != nil {
+			logger.Bg().Fatal("Migration failed", zap.Error(err))
+		}
+	case "config":
+		if flag.NArg() != 2 || flag.Arg(1) != "print" {
+			fmt.Fprintln(os.Stderr, "usage: config print")
+			os.Exit(2)
 		}
+		// secrets are masked so the output can be shared
+		out, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			logger.Bg().Fatal("Cannot print configuration", zap.Error(err))
+		}
+		os.Stdout.Write(out)
+	default:
+		flag.Usage()
+		os.Exit(2)
 	}
 }
-
-func main() {
-	// Initialize tracer
-	cleanup := initTracer()
-	defer cleanup()
-
-	// Connect to database
-	models.ConnectDatabase()
-
-	// Initialize services and handlers
-	bookService := services.NewBookService()
-	bookHandler := handlers.NewBookHandler(bookService)
-
-	// Set up router with OpenTelemetry instrumentation
-	r := mux.NewRouter()
-	r.Use(otelmux.Middleware("book-service"))
-
-	// Register routes
-	r.HandleFunc("/books", bookHandler.CreateBook).Methods("POST")
-	r.HandleFunc("/books", bookHandler.ListBooks).Methods("GET")
-	r.HandleFunc("/books/{id}", bookHandler.GetBook).Methods("GET")
-	r.HandleFunc("/books/{id}", bookHandler.UpdateBook).Methods("PUT")
-	r.HandleFunc("/books/{id}", bookHandler.DeleteBook).Methods("DELETE")
-
-	// Start server
-	log.Println("Server is running on port 8090")
-	log.Fatal(http.ListenAndServe(":8090", r))
-}
\ No newline at end of file
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	goexpvar "expvar"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"sample-app/config"
+	"sample-app/pkg/metrics"
+	"sample-app/pkg/metrics/expvar"
+	"sample-app/pkg/metrics/otelmetrics"
+	"sample-app/pkg/metrics/prometheus"
+	"sample-app/pkg/otelsemconv"
+
+	promclient "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// telemetryMetrics is the metrics backend of the service.
+type telemetryMetrics struct {
+	// Factory creates the metrics of the service, prefixed with its name.
+	Factory metrics.Factory
+	// Handler serves the metrics at Path, nil unless they are exported
+	// with prometheus or expvar.
+	Handler http.Handler
+	Path    string
+	// Shutdown exports the pending metrics of push exporters.
+	Shutdown func(ctx context.Context) error
+}
+
+// initMetrics sets up the metrics exporter selected by cfg.
+func initMetrics(cfg *config.Config) (*telemetryMetrics, error) {
+	ns := metrics.NSOptions{Name: cfg.ServiceName}
+	noShutdown := func(context.Context) error { return nil }
+
+	var exporter sdkmetric.Exporter
+	var err error
+	switch cfg.Metrics.Exporter {
+	case "prometheus":
+		registry := promclient.NewRegistry()
+		registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+		return &telemetryMetrics{
+			Factory:  prometheus.New(prometheus.WithRegisterer(registry)).Namespace(ns),
+			Handler:  prometheus.Handler(registry),
+			Path:     "/metrics",
+			Shutdown: noShutdown,
+		}, nil
+	case "expvar":
+		return &telemetryMetrics{
+			Factory:  expvar.NewFactory(0).Namespace(ns),
+			Handler:  goexpvar.Handler(),
+			Path:     "/debug/vars",
+			Shutdown: noShutdown,
+		}, nil
+	case "otlp":
+		exporter, err = otlpmetrichttp.New(context.Background(), otlpMetricOptions(cfg.Tracing)...)
+	case "stdout":
+		exporter, err = stdoutmetric.New()
+	default:
+		return &telemetryMetrics{Factory: metrics.NullFactory, Shutdown: noShutdown}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(
+		context.Background(),
+		resource.WithSchemaURL(otelsemconv.SchemaURL),
+		resource.WithAttributes(otelsemconv.ServiceNameKey.String(cfg.ServiceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithOSType(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter,
+			sdkmetric.WithInterval(time.Duration(cfg.Metrics.Interval)))),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(mp)
+	return &telemetryMetrics{
+		Factory:  otelmetrics.New(mp).Namespace(ns),
+		Shutdown: mp.Shutdown,
+	}, nil
+}
+
+// otlpMetricOptions sends metrics to the collector spans are exported to.
+func otlpMetricOptions(tracing config.TracingConfig) []otlpmetrichttp.Option {
+	var opts []otlpmetrichttp.Option
+	if tracing.Endpoint != "" {
+		// the scheme of the URL decides whether TLS is used
+		opts = append(opts, otlpmetrichttp.WithEndpointURL(strings.TrimSuffix(tracing.Endpoint, "/")+"/v1/metrics"))
+	} else if !strings.HasPrefix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "https://") &&
+		strings.ToLower(os.Getenv("OTEL_EXPORTER_OTLP_INSECURE")) != "false" {
+		// like spans, metrics are sent in plain text unless asked otherwise
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	}
+	if len(tracing.Headers) > 0 {
+		opts = append(opts, otlpmetrichttp.WithHeaders(tracing.Headers))
+	}
+	return opts
+}
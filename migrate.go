@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"sample-app/config"
+	"sample-app/models"
+	"sample-app/pkg/migrate"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up | down [n] | status | unlock")
+
+// withUnlockHint tells how to release a migration lock left by an instance
+// that died on another host, which is not released automatically.
+func withUnlockHint(err error) error {
+	if errors.Is(err, migrate.ErrLocked) {
+		return fmt.Errorf("%w; if no instance is migrating, release the lock with `migrate unlock`", err)
+	}
+	return err
+}
+
+// runMigrate implements the migrate subcommand on the database configured
+// in cfg, reporting to out.
+func runMigrate(ctx context.Context, out io.Writer, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	if cfg.Store.Type != "sqlite" {
+		return fmt.Errorf("the %s store has no schema to migrate", cfg.Store.Type)
+	}
+	db, err := models.OpenDatabase(cfg.Store.Path)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	migrator, err := models.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(out, "applied %d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+		return withUnlockHint(err)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("the number of migrations to revert must be a positive integer, not %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(out, "reverted %d_%s\n", m.Version, m.Name)
+		}
+		return withUnlockHint(err)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Unknown {
+				applied += " (unknown to this binary)"
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return tw.Flush()
+	case "unlock":
+		if err := migrator.Unlock(ctx); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "migration lock released")
+		return nil
+	}
+	return errMigrateUsage
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Author is a person credited for books. Authors are identified by their
+// name key, so spellings that only differ in case, spacing or punctuation,
+// such as "J. K. Rowling" and "JK Rowling", are the same author.
+type Author struct {
+	ID        uint      `json:"id" gorm:"primary_key"`
+	Name      string    `json:"name" gorm:"not null"`
+	NameKey   string    `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ResetServerFields clears the fields of the author that the store
+// maintains, so that the values clients send for them are ignored: its ID
+// and timestamps.
+func (a *Author) ResetServerFields() {
+	a.ID = 0
+	a.CreatedAt = time.Time{}
+	a.UpdatedAt = time.Time{}
+}
+
+// BookAuthor links a book to one of its authors. Position orders the
+// authors of a book as they are credited.
+type BookAuthor struct {
+	BookID   uint `gorm:"primaryKey;autoIncrement:false"`
+	AuthorID uint `gorm:"primaryKey;autoIncrement:false;index"`
+	Position int  `gorm:"not null"`
+}
+
+// ErrAuthorInUse is returned when deleting an author still credited for books.
+var ErrAuthorInUse = errors.New("author is credited for books")
+
+// AuthorQuery selects a page of authors, ordered by name.
+type AuthorQuery struct {
+	// NamePrefix filters on a case-insensitive prefix of the name.
+	NamePrefix string
+	Offset     int
+	// Limit is the maximum number of authors returned, 0 means no limit.
+	Limit int
+}
+
+// AuthorPage is the result of an AuthorQuery.
+type AuthorPage struct {
+	Authors []Author
+	// HasMore reports whether more authors follow this page.
+	HasMore bool
+}
+
+// AuthorKey returns the key identifying an author name: its letters and
+// digits, lowercased.
+func AuthorKey(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+var bylineSeparator = regexp.MustCompile(`(?i)\s*(?:[;&]|\band\b)\s*`)
+
+// SplitAuthors splits a byline such as "Terry Pratchett & Neil Gaiman" into
+// the names of its authors. Names are separated by semicolons, ampersands or
+// "and", and by commas only when every part is a full name, as in
+// "Terry Pratchett, Neil Gaiman": a comma may also put the surname first,
+// as in "Rowling, J. K.". Repeated names are returned once.
+//
+// ok is false, and no names are returned, if the byline has a comma that
+// could be either.
+func SplitAuthors(byline string) (names []string, ok bool) {
+	seen := make(map[string]bool)
+	for _, part := range bylineSeparator.Split(byline, -1) {
+		list := slices.DeleteFunc(strings.Split(part, ","), func(name string) bool {
+			return AuthorKey(name) == ""
+		})
+		if len(list) > 1 && slices.ContainsFunc(list, func(name string) bool { return !isFullName(name) }) {
+			return nil, false
+		}
+		for _, name := range list {
+			name = strings.TrimSpace(name)
+			key := AuthorKey(name)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	return names, true
+}
+
+// isFullName reports whether name has a given name and a surname, that
+// is at least two words of which one is not an initial.
+func isFullName(name string) bool {
+	words := strings.Fields(name)
+	return len(words) >= 2 && slices.ContainsFunc(words, func(w string) bool { return !isInitials(w) })
+}
+
+// isInitials reports whether word is made of initials, such as "J." or "J.K.".
+func isInitials(word string) bool {
+	for _, initial := range strings.Split(word, ".") {
+		if len([]rune(AuthorKey(initial))) > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// JoinAuthors builds a byline from the names of authors.
+func JoinAuthors(authors []Author) string {
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = author.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitAuthors(t *testing.T) {
+	tests := []struct {
+		byline string
+		want   []string
+		ok     bool
+	}{
+		{"Terry Pratchett & Neil Gaiman", []string{"Terry Pratchett", "Neil Gaiman"}, true},
+		{"Terry Pratchett and Neil Gaiman", []string{"Terry Pratchett", "Neil Gaiman"}, true},
+		{"Terry Pratchett; Neil Gaiman", []string{"Terry Pratchett", "Neil Gaiman"}, true},
+		{"Terry Pratchett, Neil Gaiman", []string{"Terry Pratchett", "Neil Gaiman"}, true},
+		{"A. Author, B. Writer, and C. Scribe", []string{"A. Author", "B. Writer", "C. Scribe"}, true},
+		{"J. K. Rowling", []string{"J. K. Rowling"}, true},
+		{"J. K. Rowling, JK Rowling", []string{"J. K. Rowling"}, true},
+		{"Neil Gaiman,", []string{"Neil Gaiman"}, true},
+		{"Andrew Hunt & David Thomas", []string{"Andrew Hunt", "David Thomas"}, true},
+		{"Rowling, J.K.", nil, false},
+		{"Tolkien, J. R. R.", nil, false},
+		{"Martin Luther King, Jr.", nil, false},
+		{"Neil Gaiman & Pratchett, Terry", nil, false},
+		{"", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.byline, func(t *testing.T) {
+			got, ok := SplitAuthors(tt.byline)
+			if !slices.Equal(got, tt.want) || ok != tt.ok {
+				t.Errorf("SplitAuthors(%q) = %q, %v; want %q, %v", tt.byline, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -2,18 +2,140 @@ package models
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type Book struct {
-	ID     uint   `json:"id" gorm:"primary_key"`
-	Title  string `json:"title"`
-	Author string `json:"author"`
+	ID    uint   `json:"id" gorm:"primary_key"`
+	Title string `json:"title"`
+	// Author is the byline as credited on the book, Authors are the
+	// authors it names.
+	Author  string   `json:"author"`
+	Authors []Author `json:"authors,omitempty" gorm:"-"`
+
+	// ISBN is stored in its ISBN-13 form and is unique among the books
+	// that have one.
+	ISBN            string `json:"isbn,omitempty" gorm:"not null;default:''"`
+	Publisher       string `json:"publisher,omitempty" gorm:"not null;default:''"`
+	PublicationYear int    `json:"publication_year,omitempty" gorm:"not null;default:0"`
+	Pages           int    `json:"pages,omitempty" gorm:"not null;default:0"`
+	// Language is a BCP 47 language tag such as "en" or "pt-BR".
+	Language    string `json:"language,omitempty" gorm:"not null;default:''"`
+	Description string `json:"description,omitempty" gorm:"not null;default:''"`
+	// Genres and Tags are the names of the taxonomy entries of the book.
+	Genres []string `json:"genres,omitempty" gorm:"-"`
+	Tags   []string `json:"tags,omitempty" gorm:"-"`
+
+	// Version starts at 1 and is incremented by every update.
+	Version   uint      `json:"version" gorm:"not null;default:1"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set while the book is in the trash.
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// ResetServerFields clears the fields of the book that the store
+// maintains, so that the values clients send for them are ignored: its ID,
+// version and timestamps.
+func (b *Book) ResetServerFields() {
+	b.ID = 0
+	b.Version = 0
+	b.UpdatedAt = time.Time{}
+	b.DeletedAt = gorm.DeletedAt{}
 }
 
+// Visibility selects books by whether they have been deleted.
+type Visibility int
+
+const (
+	// ActiveBooks are the books that have not been deleted.
+	ActiveBooks Visibility = iota
+	// DeletedBooks are the books in the trash.
+	DeletedBooks
+	// AllBooks includes both active and deleted books.
+	AllBooks
+)
+
+func (v Visibility) includes(book *Book) bool {
+	switch v {
+	case ActiveBooks:
+		return !book.DeletedAt.Valid
+	case DeletedBooks:
+		return book.DeletedAt.Valid
+	}
+	return true
+}
+
+// ErrDuplicateISBN is returned when a book is written with the ISBN of
+// another book, including books in the trash.
+var ErrDuplicateISBN = errors.New("ISBN is already used by another book")
+
+// ErrVersionConflict is returned when a write expects a version of a book
+// that is no longer the stored one.
+var ErrVersionConflict = errors.New("version conflict")
+
+// Interface is the storage used by services.BookService. Implementations
+// report missing books with gorm.ErrRecordNotFound.
+//
+// DeleteBook moves a book to the trash, from where RestoreBook brings it
+// back and PurgeBooks removes it for good. Deleted books are only visible
+// to reads that ask for them, and cannot be updated.
+//
+// UpdateBook and DeleteBook take the version the book is expected to have,
+// or 0 to skip the check, and fail with ErrVersionConflict on mismatch.
+// UpdateBook reads the expected version from book.Version and sets it to
+// the new version on success.
+//
+// Books are read with their Authors, Genres and Tags, which writes of a
+// book ignore: they are linked with SetBookAuthors and SetBookTags.
+//
+// SearchBooks ignores case and diacritics, except in an SQLStore without
+// the FTS5 index, which only ignores the case of ASCII letters. Prefix
+// filters only ignore the case of ASCII letters in every store.
 type Interface interface {
 	CreateBook(ctx context.Context, book *Book) error
-	GetBook(ctx context.Context, id uint) (*Book, error)
-	ListBooks(ctx context.Context) ([]Book, error)
+	GetBook(ctx context.Context, id uint, visibility Visibility) (*Book, error)
+	ListBooks(ctx context.Context, q BookQuery) (*BookPage, error)
+	SearchBooks(ctx context.Context, q SearchQuery) ([]SearchResult, error)
 	UpdateBook(ctx context.Context, book *Book) error
-	DeleteBook(ctx context.Context, id uint) error
-}
\ No newline at end of file
+	DeleteBook(ctx context.Context, id uint, version uint) error
+	RestoreBook(ctx context.Context, id uint) (*Book, error)
+	// PurgeBooks permanently removes the books deleted before the given
+	// time and returns how many were removed.
+	PurgeBooks(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+	// AddRevision appends rev to the history of rev.BookID, assigning
+	// it the next revision number.
+	AddRevision(ctx context.Context, rev *BookRevision) error
+	// ListRevisions returns the history of a book, oldest first.
+	ListRevisions(ctx context.Context, bookID uint) ([]BookRevision, error)
+	GetRevision(ctx context.Context, bookID uint, revision uint) (*BookRevision, error)
+
+	CreateAuthor(ctx context.Context, author *Author) error
+	GetAuthor(ctx context.Context, id uint) (*Author, error)
+	// FirstOrCreateAuthor loads the author with the name key of
+	// author.Name into author, creating it if there is none.
+	FirstOrCreateAuthor(ctx context.Context, author *Author) error
+	ListAuthors(ctx context.Context, q AuthorQuery) (*AuthorPage, error)
+	UpdateAuthor(ctx context.Context, author *Author) error
+	// DeleteAuthor fails with ErrAuthorInUse while books, including
+	// deleted ones, are linked to the author.
+	DeleteAuthor(ctx context.Context, id uint) error
+	// SetBookAuthors replaces the authors of a book, in credit order.
+	SetBookAuthors(ctx context.Context, bookID uint, authorIDs []uint) error
+
+	// FirstOrCreateTag loads the tag with the kind and slug of tag.Name
+	// into tag, creating it if there is none.
+	FirstOrCreateTag(ctx context.Context, tag *Tag) error
+	// ListTags returns the tags of a kind, or of every kind if empty,
+	// ordered by slug.
+	ListTags(ctx context.Context, kind string) ([]Tag, error)
+	// SetBookTags replaces the genres and tags of a book.
+	SetBookTags(ctx context.Context, bookID uint, tagIDs []uint) error
+
+	// Transaction runs fn atomically: the changes fn makes through tx
+	// are only kept if it returns nil.
+	Transaction(ctx context.Context, fn func(tx Interface) error) error
+}
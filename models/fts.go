@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// The books_fts FTS5 table indexes book titles and authors. It is an
+// external content table kept in sync with books by triggers.
+var ftsTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS books_fts_ai AFTER INSERT ON books BEGIN
+		INSERT INTO books_fts(rowid, title, author) VALUES (new.id, new.title, new.author);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS books_fts_ad AFTER DELETE ON books BEGIN
+		INSERT INTO books_fts(books_fts, rowid, title, author) VALUES ('delete', old.id, old.title, old.author);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS books_fts_au AFTER UPDATE ON books BEGIN
+		INSERT INTO books_fts(books_fts, rowid, title, author) VALUES ('delete', old.id, old.title, old.author);
+		INSERT INTO books_fts(rowid, title, author) VALUES (new.id, new.title, new.author);
+	END`,
+}
+
+// SetupFullTextSearch creates the books_fts index and its triggers. SQLite
+// only ships FTS5 when built with the sqlite_fts5 tag; without it the
+// triggers are dropped so writes keep working, and the index is rebuilt
+// the next time FTS5 is available.
+func SetupFullTextSearch(db *gorm.DB) error {
+	var enabled bool
+	if err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled).Error; err != nil {
+		return err
+	}
+	if !enabled {
+		for _, name := range []string{"books_fts_ai", "books_fts_ad", "books_fts_au"} {
+			if err := db.Exec("DROP TRIGGER IF EXISTS " + name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var triggers int64
+	err := db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'books_fts_%'").
+		Scan(&triggers).Error
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		stmts := append([]string{
+			`CREATE VIRTUAL TABLE IF NOT EXISTS books_fts USING fts5(
+				title, author, content='books', content_rowid='id', tokenize='unicode61 remove_diacritics 2')`,
+		}, ftsTriggers...)
+		if triggers < int64(len(ftsTriggers)) {
+			// the index is new or missed writes while the triggers were gone
+			stmts = append(stmts, "INSERT INTO books_fts(books_fts) VALUES ('rebuild')")
+		}
+		for _, stmt := range stmts {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// hasFullTextIndex reports whether books_fts is available and maintained.
+func hasFullTextIndex(db *gorm.DB) bool {
+	var count int64
+	err := db.Raw(`SELECT count(*) FROM sqlite_master
+		WHERE type = 'trigger' AND name LIKE 'books_fts_%' AND sqlite_compileoption_used('ENABLE_FTS5')`).
+		Scan(&count).Error
+	return err == nil && count == int64(len(ftsTriggers))
+}
+
+// ftsMatch builds an FTS5 query requiring every term as a word prefix.
+func ftsMatch(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	return strings.Join(quoted, " ")
+}
+
+type ftsRow struct {
+	Book
+	Rank            float64
+	TitleHighlight  string
+	AuthorHighlight string
+}
+
+func (s *SQLStore) searchFTS(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	var rows []ftsRow
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT books.*,
+			bm25(books_fts, 2.0, 1.0) AS rank,
+			highlight(books_fts, 0, ?, ?) AS title_highlight,
+			highlight(books_fts, 1, ?, ?) AS author_highlight
+		FROM books_fts JOIN books ON books.id = books_fts.rowid
+		WHERE books_fts MATCH ? AND books.deleted_at IS NULL
+		ORDER BY rank, books.id
+		LIMIT ?`,
+		highlightStart, highlightEnd, highlightStart, highlightEnd, ftsMatch(q.Terms), q.Limit,
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			Book: row.Book,
+			// bm25 is lower for better matches
+			Score: -row.Rank,
+			Highlights: Highlights{
+				Title:  renderHighlight(row.TitleHighlight),
+				Author: renderHighlight(row.AuthorHighlight),
+			},
+		}
+	}
+	if err := withResultRelations(s.db.WithContext(ctx), results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var errNotConnected = errors.New("database is not connected")
+
+// PingDatabase checks that the database connected by ConnectDatabase
+// answers. It is not traced.
+func PingDatabase(ctx context.Context) error {
+	if untraced == nil {
+		return errNotConnected
+	}
+	sqlDB, err := untraced.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckMigrations returns an error if migrations known to this binary have
+// not been applied to the database connected by ConnectDatabase. It only
+// reads schema_migrations and is not traced.
+func CheckMigrations(ctx context.Context) error {
+	if schema == nil {
+		return errNotConnected
+	}
+	pending, err := schema.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d migrations are pending", pending)
+	}
+	return nil
+}
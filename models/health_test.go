@@ -0,0 +1,44 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthChecks(t *testing.T) {
+	ctx := context.Background()
+	if err := CheckMigrations(ctx); !errors.Is(err, errNotConnected) {
+		t.Errorf("got %v before connecting, want errNotConnected", err)
+	}
+
+	if _, err := ConnectDatabase(ctx, filepath.Join(t.TempDir(), "books.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		CloseDatabase()
+		DB, untraced, schema = nil, nil, nil
+	})
+
+	if err := PingDatabase(ctx); err != nil {
+		t.Errorf("ping: %v", err)
+	}
+	if err := CheckMigrations(ctx); err != nil {
+		t.Errorf("migrations: %v", err)
+	}
+
+	// the check only reads, it must not recreate the lock table
+	if err := untraced.Migrator().DropTable("schema_migrations_lock"); err != nil {
+		t.Fatal(err)
+	}
+	if err := untraced.Exec("DELETE FROM schema_migrations WHERE version = (SELECT MAX(version) FROM schema_migrations)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckMigrations(ctx); err == nil || err.Error() != "1 migrations are pending" {
+		t.Errorf("got %v, want 1 pending migration", err)
+	}
+	if untraced.Migrator().HasTable("schema_migrations_lock") {
+		t.Error("CheckMigrations created the lock table")
+	}
+}
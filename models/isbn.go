@@ -0,0 +1,64 @@
+package models
+
+import (
+	"strings"
+)
+
+// NormalizeISBN strips the separators from an ISBN and converts a valid
+// ISBN-10 to its ISBN-13 form, so every edition has a single spelling.
+// Invalid ISBNs are returned without separators.
+func NormalizeISBN(isbn string) string {
+	isbn = strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(isbn)))
+	if len(isbn) == 10 && ValidISBN(isbn) {
+		isbn = "978" + isbn[:9]
+		return isbn + string(isbn13CheckDigit(isbn))
+	}
+	return isbn
+}
+
+// ValidISBN reports whether isbn, without separators, is an ISBN-10 or
+// ISBN-13 with a correct check digit.
+func ValidISBN(isbn string) bool {
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i := 0; i < 10; i++ {
+			c := isbn[i]
+			var d int
+			switch {
+			case c >= '0' && c <= '9':
+				d = int(c - '0')
+			case c == 'X' && i == 9:
+				d = 10
+			default:
+				return false
+			}
+			sum += (10 - i) * d
+		}
+		return sum%11 == 0
+	case 13:
+		if strings.Trim(isbn, "0123456789") != "" {
+			return false
+		}
+		return isbn13CheckDigit(isbn[:12]) == isbn[12]
+	}
+	return false
+}
+
+// isbn13CheckDigit computes the check digit of the first 12 digits of an ISBN-13.
+func isbn13CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
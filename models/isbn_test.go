@@ -0,0 +1,28 @@
+package models
+
+import "testing"
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		isbn, want string
+		valid      bool
+	}{
+		{"978-0-441-17271-9", "9780441172719", true},
+		{" 0-441-17271-7 ", "9780441172719", true},
+		{"0-8044-2957-X", "9780804429573", true},
+		{"0-8044-2957-x", "9780804429573", true},
+		{"978 0 441 17271 0", "9780441172710", false},
+		{"0-441-17271-8", "0441172718", false},
+		{"X-441-17271-7", "X441172717", false},
+		{"12345", "12345", false},
+	}
+	for _, tt := range tests {
+		got := NormalizeISBN(tt.isbn)
+		if got != tt.want {
+			t.Errorf("NormalizeISBN(%q) = %q, want %q", tt.isbn, got, tt.want)
+		}
+		if ValidISBN(got) != tt.valid {
+			t.Errorf("ValidISBN(%q) = %v, want %v", got, !tt.valid, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,574 @@
+package models
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MemoryStore implements Interface by keeping books in memory. It mirrors
+// the behaviour of SQLStore, including returning gorm.ErrRecordNotFound for
+// missing books, so the two can be used interchangeably.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data *memData
+}
+
+var _ Interface = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: &memData{
+			books:        make(map[uint]Book),
+			revisions:    make(map[uint][]BookRevision),
+			authors:      make(map[uint]Author),
+			bookAuthors:  make(map[uint][]uint),
+			tags:         make(map[uint]Tag),
+			bookTags:     make(map[uint][]uint),
+			nextID:       1,
+			nextAuthorID: 1,
+			nextTagID:    1,
+		},
+	}
+}
+
+func (s *MemoryStore) CreateBook(ctx context.Context, book *Book) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.CreateBook(ctx, book)
+}
+
+func (s *MemoryStore) GetBook(ctx context.Context, id uint, visibility Visibility) (*Book, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.GetBook(ctx, id, visibility)
+}
+
+func (s *MemoryStore) ListBooks(ctx context.Context, q BookQuery) (*BookPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.ListBooks(ctx, q)
+}
+
+func (s *MemoryStore) SearchBooks(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.SearchBooks(ctx, q)
+}
+
+func (s *MemoryStore) UpdateBook(ctx context.Context, book *Book) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.UpdateBook(ctx, book)
+}
+
+func (s *MemoryStore) DeleteBook(ctx context.Context, id uint, version uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.DeleteBook(ctx, id, version)
+}
+
+func (s *MemoryStore) RestoreBook(ctx context.Context, id uint) (*Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.RestoreBook(ctx, id)
+}
+
+func (s *MemoryStore) PurgeBooks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.PurgeBooks(ctx, deletedBefore)
+}
+
+func (s *MemoryStore) AddRevision(ctx context.Context, rev *BookRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.AddRevision(ctx, rev)
+}
+
+func (s *MemoryStore) ListRevisions(ctx context.Context, bookID uint) ([]BookRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.ListRevisions(ctx, bookID)
+}
+
+func (s *MemoryStore) GetRevision(ctx context.Context, bookID uint, revision uint) (*BookRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.GetRevision(ctx, bookID, revision)
+}
+
+func (s *MemoryStore) CreateAuthor(ctx context.Context, author *Author) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.CreateAuthor(ctx, author)
+}
+
+func (s *MemoryStore) GetAuthor(ctx context.Context, id uint) (*Author, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.GetAuthor(ctx, id)
+}
+
+func (s *MemoryStore) FirstOrCreateAuthor(ctx context.Context, author *Author) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.FirstOrCreateAuthor(ctx, author)
+}
+
+func (s *MemoryStore) ListAuthors(ctx context.Context, q AuthorQuery) (*AuthorPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.ListAuthors(ctx, q)
+}
+
+func (s *MemoryStore) UpdateAuthor(ctx context.Context, author *Author) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.UpdateAuthor(ctx, author)
+}
+
+func (s *MemoryStore) DeleteAuthor(ctx context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.DeleteAuthor(ctx, id)
+}
+
+func (s *MemoryStore) SetBookAuthors(ctx context.Context, bookID uint, authorIDs []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.SetBookAuthors(ctx, bookID, authorIDs)
+}
+
+func (s *MemoryStore) FirstOrCreateTag(ctx context.Context, tag *Tag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.FirstOrCreateTag(ctx, tag)
+}
+
+func (s *MemoryStore) ListTags(ctx context.Context, kind string) ([]Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.ListTags(ctx, kind)
+}
+
+func (s *MemoryStore) SetBookTags(ctx context.Context, bookID uint, tagIDs []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.SetBookTags(ctx, bookID, tagIDs)
+}
+
+// Transaction runs fn on a copy of the store contents while holding the
+// write lock, and keeps the copy only if fn succeeds.
+func (s *MemoryStore) Transaction(ctx context.Context, fn func(tx Interface) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := s.data.clone()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.data = tx
+	return nil
+}
+
+// memData holds the contents of a MemoryStore. It implements Interface
+// without any locking, callers are responsible for synchronization.
+type memData struct {
+	books     map[uint]Book
+	revisions map[uint][]BookRevision
+	authors   map[uint]Author
+	// bookAuthors holds the IDs of the authors of each book, in credit order.
+	bookAuthors map[uint][]uint
+	tags        map[uint]Tag
+	bookTags    map[uint][]uint
+
+	nextID       uint
+	nextRevID    uint
+	nextAuthorID uint
+	nextTagID    uint
+}
+
+// clone copies the data. Revision and link slices are shared, which is
+// safe as revisions are only ever appended to and links replaced.
+func (d *memData) clone() *memData {
+	return &memData{
+		books:        maps.Clone(d.books),
+		revisions:    maps.Clone(d.revisions),
+		authors:      maps.Clone(d.authors),
+		bookAuthors:  maps.Clone(d.bookAuthors),
+		tags:         maps.Clone(d.tags),
+		bookTags:     maps.Clone(d.bookTags),
+		nextID:       d.nextID,
+		nextRevID:    d.nextRevID,
+		nextAuthorID: d.nextAuthorID,
+		nextTagID:    d.nextTagID,
+	}
+}
+
+func (d *memData) CreateBook(_ context.Context, book *Book) error {
+	if d.isbnTaken(book) {
+		return ErrDuplicateISBN
+	}
+	if book.ID == 0 {
+		book.ID = d.nextID
+	} else if _, ok := d.books[book.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	book.Version = 1
+	book.UpdatedAt = time.Now()
+	d.put(*book)
+	return nil
+}
+
+func (d *memData) GetBook(_ context.Context, id uint, visibility Visibility) (*Book, error) {
+	book, ok := d.books[id]
+	if !ok || !visibility.includes(&book) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	d.withRelations(&book)
+	return &book, nil
+}
+
+func (d *memData) ListBooks(_ context.Context, q BookQuery) (*BookPage, error) {
+	books := d.all()
+	if q.AuthorID != 0 {
+		books = slices.DeleteFunc(books, func(book Book) bool {
+			return !slices.Contains(d.bookAuthors[book.ID], q.AuthorID)
+		})
+	}
+	if q.Genre != "" || q.Tag != "" {
+		books = slices.DeleteFunc(books, func(book Book) bool {
+			return (q.Genre != "" && !d.hasTag(book.ID, TagKindGenre, q.Genre)) ||
+				(q.Tag != "" && !d.hasTag(book.ID, TagKindTag, q.Tag))
+		})
+	}
+	page := queryBooks(books, q)
+	for i := range page.Books {
+		d.withRelations(&page.Books[i])
+	}
+	return &page, nil
+}
+
+func (d *memData) SearchBooks(_ context.Context, q SearchQuery) ([]SearchResult, error) {
+	results := searchBooks(d.all(), q)
+	for i := range results {
+		d.withRelations(&results[i].Book)
+	}
+	return results, nil
+}
+
+func (d *memData) UpdateBook(_ context.Context, book *Book) error {
+	current, err := d.checkVersion(book.ID, book.Version)
+	if err != nil {
+		return err
+	}
+	if d.isbnTaken(book) {
+		return ErrDuplicateISBN
+	}
+	book.Version = current.Version + 1
+	book.UpdatedAt = time.Now()
+	book.DeletedAt = current.DeletedAt
+	d.put(*book)
+	return nil
+}
+
+func (d *memData) DeleteBook(_ context.Context, id uint, version uint) error {
+	book, err := d.checkVersion(id, version)
+	if err != nil {
+		return err
+	}
+	book.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	d.put(*book)
+	return nil
+}
+
+func (d *memData) RestoreBook(_ context.Context, id uint) (*Book, error) {
+	book, ok := d.books[id]
+	if !ok || !book.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	book.DeletedAt = gorm.DeletedAt{}
+	book.Version++
+	book.UpdatedAt = time.Now()
+	d.put(book)
+	d.withRelations(&book)
+	return &book, nil
+}
+
+func (d *memData) PurgeBooks(_ context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	for id, book := range d.books {
+		if book.DeletedAt.Valid && book.DeletedAt.Time.Before(deletedBefore) {
+			delete(d.books, id)
+			delete(d.bookAuthors, id)
+			delete(d.bookTags, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (d *memData) AddRevision(_ context.Context, rev *BookRevision) error {
+	d.nextRevID++
+	rev.ID = d.nextRevID
+	rev.Revision = uint(len(d.revisions[rev.BookID])) + 1
+	rev.CreatedAt = time.Now()
+	stored := *rev
+	stored.Before, stored.After = snapshot(rev.Before), snapshot(rev.After)
+	d.revisions[rev.BookID] = append(d.revisions[rev.BookID], stored)
+	return nil
+}
+
+// snapshot copies a book so later changes to it do not alter the history.
+func snapshot(book *Book) *Book {
+	if book == nil {
+		return nil
+	}
+	c := *book
+	return &c
+}
+
+func (d *memData) ListRevisions(_ context.Context, bookID uint) ([]BookRevision, error) {
+	return slices.Clone(d.revisions[bookID]), nil
+}
+
+func (d *memData) GetRevision(_ context.Context, bookID uint, revision uint) (*BookRevision, error) {
+	revs := d.revisions[bookID]
+	if revision == 0 || revision > uint(len(revs)) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	rev := revs[revision-1]
+	return &rev, nil
+}
+
+func (d *memData) CreateAuthor(_ context.Context, author *Author) error {
+	author.NameKey = AuthorKey(author.Name)
+	if author.ID == 0 {
+		author.ID = d.nextAuthorID
+	} else if _, ok := d.authors[author.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	if _, ok := d.authorByKey(author.NameKey); ok {
+		return gorm.ErrDuplicatedKey
+	}
+	author.CreatedAt = time.Now()
+	author.UpdatedAt = author.CreatedAt
+	d.authors[author.ID] = *author
+	if author.ID >= d.nextAuthorID {
+		d.nextAuthorID = author.ID + 1
+	}
+	return nil
+}
+
+func (d *memData) GetAuthor(_ context.Context, id uint) (*Author, error) {
+	author, ok := d.authors[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &author, nil
+}
+
+func (d *memData) FirstOrCreateAuthor(ctx context.Context, author *Author) error {
+	if found, ok := d.authorByKey(AuthorKey(author.Name)); ok {
+		*author = found
+		return nil
+	}
+	return d.CreateAuthor(ctx, author)
+}
+
+func (d *memData) ListAuthors(_ context.Context, q AuthorQuery) (*AuthorPage, error) {
+	var authors []Author
+	for _, author := range d.authors {
+		if hasPrefixFold(author.Name, q.NamePrefix) {
+			authors = append(authors, author)
+		}
+	}
+	slices.SortFunc(authors, func(a, b Author) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	var page AuthorPage
+	if q.Offset < len(authors) {
+		page.Authors = authors[q.Offset:]
+	}
+	if q.Limit > 0 && len(page.Authors) > q.Limit {
+		page.Authors = page.Authors[:q.Limit]
+		page.HasMore = true
+	}
+	return &page, nil
+}
+
+func (d *memData) UpdateAuthor(_ context.Context, author *Author) error {
+	current, ok := d.authors[author.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	author.NameKey = AuthorKey(author.Name)
+	if other, ok := d.authorByKey(author.NameKey); ok && other.ID != author.ID {
+		return gorm.ErrDuplicatedKey
+	}
+	author.CreatedAt = current.CreatedAt
+	author.UpdatedAt = time.Now()
+	d.authors[author.ID] = *author
+	return nil
+}
+
+func (d *memData) DeleteAuthor(_ context.Context, id uint) error {
+	if _, ok := d.authors[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	for _, ids := range d.bookAuthors {
+		if slices.Contains(ids, id) {
+			return ErrAuthorInUse
+		}
+	}
+	delete(d.authors, id)
+	return nil
+}
+
+func (d *memData) SetBookAuthors(_ context.Context, bookID uint, authorIDs []uint) error {
+	for _, id := range authorIDs {
+		if _, ok := d.authors[id]; !ok {
+			return gorm.ErrForeignKeyViolated
+		}
+	}
+	if len(authorIDs) == 0 {
+		delete(d.bookAuthors, bookID)
+	} else {
+		d.bookAuthors[bookID] = slices.Clone(authorIDs)
+	}
+	return nil
+}
+
+func (d *memData) authorByKey(key string) (Author, bool) {
+	for _, author := range d.authors {
+		if author.NameKey == key {
+			return author, true
+		}
+	}
+	return Author{}, false
+}
+
+func (d *memData) FirstOrCreateTag(_ context.Context, tag *Tag) error {
+	tag.Slug = TagSlug(tag.Name)
+	for _, found := range d.tags {
+		if found.Kind == tag.Kind && found.Slug == tag.Slug {
+			*tag = found
+			return nil
+		}
+	}
+	tag.ID = d.nextTagID
+	d.nextTagID++
+	d.tags[tag.ID] = *tag
+	return nil
+}
+
+func (d *memData) ListTags(_ context.Context, kind string) ([]Tag, error) {
+	var tags []Tag
+	for _, tag := range d.tags {
+		if kind == "" || tag.Kind == kind {
+			tags = append(tags, tag)
+		}
+	}
+	slices.SortFunc(tags, func(a, b Tag) int {
+		if c := strings.Compare(a.Slug, b.Slug); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Kind, b.Kind)
+	})
+	return tags, nil
+}
+
+func (d *memData) SetBookTags(_ context.Context, bookID uint, tagIDs []uint) error {
+	for _, id := range tagIDs {
+		if _, ok := d.tags[id]; !ok {
+			return gorm.ErrForeignKeyViolated
+		}
+	}
+	if len(tagIDs) == 0 {
+		delete(d.bookTags, bookID)
+	} else {
+		d.bookTags[bookID] = slices.Clone(tagIDs)
+	}
+	return nil
+}
+
+// withRelations fills in the authors, in credit order, genres and tags of a book.
+func (d *memData) withRelations(book *Book) {
+	book.Authors = nil
+	for _, id := range d.bookAuthors[book.ID] {
+		book.Authors = append(book.Authors, d.authors[id])
+	}
+	var tags []Tag
+	for _, id := range d.bookTags[book.ID] {
+		tags = append(tags, d.tags[id])
+	}
+	setBookTags(book, tags)
+}
+
+func (d *memData) hasTag(bookID uint, kind, slug string) bool {
+	return slices.ContainsFunc(d.bookTags[bookID], func(id uint) bool {
+		tag := d.tags[id]
+		return tag.Kind == kind && tag.Slug == slug
+	})
+}
+
+// isbnTaken reports whether another book, deleted or not, has the ISBN of book.
+func (d *memData) isbnTaken(book *Book) bool {
+	if book.ISBN == "" {
+		return false
+	}
+	for id, other := range d.books {
+		if id != book.ID && other.ISBN == book.ISBN {
+			return true
+		}
+	}
+	return false
+}
+
+// Transaction runs fn directly, nested transactions are part of the outer one.
+func (d *memData) Transaction(_ context.Context, fn func(tx Interface) error) error {
+	return fn(d)
+}
+
+// checkVersion returns the stored book, failing if its version does not
+// match the expected non-zero version.
+func (d *memData) checkVersion(id uint, expected uint) (*Book, error) {
+	book, ok := d.books[id]
+	if !ok || book.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if expected != 0 && expected != book.Version {
+		return nil, ErrVersionConflict
+	}
+	return &book, nil
+}
+
+func (d *memData) all() []Book {
+	books := make([]Book, 0, len(d.books))
+	for _, book := range d.books {
+		books = append(books, book)
+	}
+	return books
+}
+
+// put stores the book and keeps nextID ahead of every stored ID.
+// Its authors, genres and tags are kept in the link maps instead.
+func (d *memData) put(book Book) {
+	book.Authors, book.Genres, book.Tags = nil, nil, nil
+	d.books[book.ID] = book
+	if book.ID >= d.nextID {
+		d.nextID = book.ID + 1
+	}
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"embed"
+	"time"
+
+	"sample-app/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var sqlMigrations embed.FS
+
+// Migrations returns the schema migrations of the book database: the Go
+// baseline followed by the SQL migrations in the migrations directory.
+func Migrations() ([]migrate.Migration, error) {
+	migrations, err := migrate.ParseFS(sqlMigrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return append([]migrate.Migration{baseline}, migrations...), nil
+}
+
+// NewMigrator creates a migrator applying Migrations to db.
+func NewMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, migrations)
+}
+
+// baseline creates the schema that used to be maintained by AutoMigrate.
+// On databases created by those releases it adds whatever they lack, so
+// every database starts the versioned history from the same schema.
+var baseline = migrate.Migration{Version: 1, Name: "baseline", Up: createBaseline}
+
+func createBaseline(tx *gorm.DB) error {
+	err := tx.AutoMigrate(&baselineBook{}, &baselineBookRevision{}, &baselineAuthor{},
+		&baselineBookAuthor{}, &baselineTag{}, &baselineBookTag{})
+	if err != nil {
+		return err
+	}
+	// AutoMigrate cannot express the condition of the index
+	err = tx.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn ON books(isbn) WHERE isbn <> ''").Error
+	if err != nil {
+		return err
+	}
+	return linkBylineAuthors(tx)
+}
+
+// linkBylineAuthors links every book that has no authors to the authors
+// named in its byline, creating them as needed. Like the schema, it only
+// uses the baseline models.
+func linkBylineAuthors(tx *gorm.DB) error {
+	var books []baselineBook
+	err := tx.Unscoped().Where("id NOT IN (SELECT book_id FROM book_authors)").Find(&books).Error
+	if err != nil {
+		return err
+	}
+
+	for _, book := range books {
+		// books with an ambiguous byline are left without authors
+		names, _ := SplitAuthors(book.Author)
+		for i, name := range names {
+			author := baselineAuthor{Name: name, NameKey: AuthorKey(name)}
+			if err := tx.Where(baselineAuthor{NameKey: author.NameKey}).FirstOrCreate(&author).Error; err != nil {
+				return err
+			}
+			link := baselineBookAuthor{BookID: book.ID, AuthorID: author.ID, Position: i}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// The models as of the baseline. Unlike the models used by the stores,
+// they must never change: later schema changes are new migrations.
+type (
+	baselineBook struct {
+		ID              uint `gorm:"primary_key"`
+		Title           string
+		Author          string
+		ISBN            string `gorm:"not null;default:''"`
+		Publisher       string `gorm:"not null;default:''"`
+		PublicationYear int    `gorm:"not null;default:0"`
+		Pages           int    `gorm:"not null;default:0"`
+		Language        string `gorm:"not null;default:''"`
+		Description     string `gorm:"not null;default:''"`
+		Version         uint   `gorm:"not null;default:1"`
+		UpdatedAt       time.Time
+		DeletedAt       gorm.DeletedAt `gorm:"index"`
+	}
+	baselineBookRevision struct {
+		ID        uint   `gorm:"primary_key"`
+		BookID    uint   `gorm:"not null;uniqueIndex:idx_book_revisions_book_revision"`
+		Revision  uint   `gorm:"not null;uniqueIndex:idx_book_revisions_book_revision"`
+		Action    string `gorm:"not null"`
+		Actor     string
+		TraceID   string
+		Before    string
+		After     string
+		CreatedAt time.Time
+	}
+	baselineAuthor struct {
+		ID        uint   `gorm:"primary_key"`
+		Name      string `gorm:"not null"`
+		NameKey   string `gorm:"not null;uniqueIndex"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+	baselineBookAuthor struct {
+		BookID   uint `gorm:"primaryKey;autoIncrement:false"`
+		AuthorID uint `gorm:"primaryKey;autoIncrement:false;index"`
+		Position int  `gorm:"not null"`
+	}
+	baselineTag struct {
+		ID   uint   `gorm:"primary_key"`
+		Kind string `gorm:"not null;uniqueIndex:idx_tags_kind_slug"`
+		Name string `gorm:"not null"`
+		Slug string `gorm:"not null;uniqueIndex:idx_tags_kind_slug"`
+	}
+	baselineBookTag struct {
+		BookID   uint `gorm:"primaryKey;autoIncrement:false"`
+		TagID    uint `gorm:"primaryKey;autoIncrement:false;index"`
+		Position int  `gorm:"not null"`
+	}
+)
+
+func (baselineBook) TableName() string         { return "books" }
+func (baselineBookRevision) TableName() string { return "book_revisions" }
+func (baselineAuthor) TableName() string       { return "authors" }
+func (baselineBookAuthor) TableName() string   { return "book_authors" }
+func (baselineTag) TableName() string          { return "tags" }
+func (baselineBookTag) TableName() string      { return "book_tags" }
@@ -0,0 +1,3 @@
+-- Index the columns books can be sorted by.
+CREATE INDEX IF NOT EXISTS idx_books_title ON books(title, id);
+CREATE INDEX IF NOT EXISTS idx_books_author ON books(author, id);
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// TestBaselineLinksBylineAuthors upgrades a database created before the
+// schema was versioned, when books only had a byline.
+func TestBaselineLinksBylineAuthors(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "books.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE TABLE books (id INTEGER PRIMARY KEY, title TEXT, author TEXT, updated_at DATETIME, deleted_at DATETIME)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec(`INSERT INTO books (id, title, author) VALUES
+		(1, 'Good Omens', 'Terry Pratchett & Neil Gaiman'),
+		(2, 'Harry Potter', 'Rowling, J.K.'),
+		(3, 'Coraline', 'Neil Gaiman')`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var links []struct {
+		BookID uint
+		Name   string
+	}
+	err = db.Raw(`SELECT book_id, name FROM book_authors JOIN authors ON authors.id = author_id
+		ORDER BY book_id, position`).Scan(&links).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		BookID uint
+		Name   string
+	}{
+		{1, "Terry Pratchett"},
+		{1, "Neil Gaiman"},
+		{3, "Neil Gaiman"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("got links %v, want %v", links, want)
+	}
+	for i := range want {
+		if links[i] != want[i] {
+			t.Errorf("link %d: got %v, want %v", i, links[i], want[i])
+		}
+	}
+}
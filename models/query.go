@@ -0,0 +1,165 @@
+package models
+
+import (
+	"sort"
+)
+
+// SortField is a column books can be ordered by.
+type SortField string
+
+const (
+	SortByID     SortField = "id"
+	SortByTitle  SortField = "title"
+	SortByAuthor SortField = "author"
+)
+
+// Valid reports whether f is a known sort field.
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByID, SortByTitle, SortByAuthor:
+		return true
+	}
+	return false
+}
+
+// Cursor is the position of a book in a sort order, used for keyset pagination.
+type Cursor struct {
+	ID uint
+	// Value is the value of the sort column, empty when sorting by ID.
+	Value string
+}
+
+// BookQuery selects a page of books. Exact filters are case-sensitive,
+// prefix filters ignore the case of ASCII letters only, like the LIKE
+// operator of SQLite: "é" does not match "É".
+type BookQuery struct {
+	Title        string
+	TitlePrefix  string
+	Author       string
+	AuthorPrefix string
+	// AuthorID restricts the query to the books linked to an author.
+	AuthorID uint
+	// Genre and Tag restrict the query to the books with the genre or
+	// tag of the given slug.
+	Genre string
+	Tag   string
+
+	Visibility Visibility
+
+	SortBy     SortField
+	Descending bool
+
+	// After restricts the page to books after the cursor in sort order.
+	After  *Cursor
+	Offset int
+	// Limit is the maximum number of books returned, 0 means no limit.
+	Limit int
+	// CountTotal requests the number of books matching the filters,
+	// regardless of After, Offset and Limit.
+	CountTotal bool
+}
+
+// BookPage is the result of a BookQuery.
+type BookPage struct {
+	Books []Book
+	// Total is the number of matching books, only set if CountTotal was requested.
+	Total int64
+	// HasMore reports whether more books follow this page.
+	HasMore bool
+}
+
+// CursorOf returns the position of the book in the given sort order.
+func CursorOf(book *Book, field SortField) Cursor {
+	return Cursor{ID: book.ID, Value: sortValue(book, field)}
+}
+
+func sortValue(book *Book, field SortField) string {
+	switch field {
+	case SortByTitle:
+		return book.Title
+	case SortByAuthor:
+		return book.Author
+	}
+	return ""
+}
+
+// queryBooks applies q to books in memory, mirroring the semantics of the SQL store.
+func queryBooks(books []Book, q BookQuery) BookPage {
+	matched := books[:0:0]
+	for _, book := range books {
+		if matchesFilters(&book, q) {
+			matched = append(matched, book)
+		}
+	}
+
+	field := q.SortBy
+	if field == "" {
+		field = SortByID
+	}
+	less := func(a, b Cursor) bool {
+		if a.Value != b.Value {
+			return a.Value < b.Value
+		}
+		return a.ID < b.ID
+	}
+	before := func(a, b Cursor) bool {
+		if q.Descending {
+			return less(b, a)
+		}
+		return less(a, b)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return before(CursorOf(&matched[i], field), CursorOf(&matched[j], field))
+	})
+
+	var page BookPage
+	if q.CountTotal {
+		page.Total = int64(len(matched))
+	}
+	if q.After != nil {
+		idx := sort.Search(len(matched), func(i int) bool {
+			return before(*q.After, CursorOf(&matched[i], field))
+		})
+		matched = matched[idx:]
+	}
+	if q.Offset >= len(matched) {
+		matched = matched[:0]
+	} else {
+		matched = matched[q.Offset:]
+	}
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+		page.HasMore = true
+	}
+	page.Books = matched
+	return page
+}
+
+func matchesFilters(book *Book, q BookQuery) bool {
+	return q.Visibility.includes(book) &&
+		(q.Title == "" || book.Title == q.Title) &&
+		(q.Author == "" || book.Author == q.Author) &&
+		hasPrefixFold(book.Title, q.TitlePrefix) &&
+		hasPrefixFold(book.Author, q.AuthorPrefix)
+}
+
+// hasPrefixFold reports whether s starts with prefix, ignoring the case of
+// ASCII letters as SQLite's LIKE does.
+func hasPrefixFold(s, prefix string) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+	for i := 0; i < len(prefix); i++ {
+		if asciiLower(s[i]) != asciiLower(prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func asciiLower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
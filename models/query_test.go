@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// titles returns the titles of the books of page.
+func titles(page *BookPage) string {
+	t := make([]string, len(page.Books))
+	for i, book := range page.Books {
+		t[i] = book.Title
+	}
+	return fmt.Sprint(t)
+}
+
+func TestStoreListBooks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Interface) {
+		ctx := context.Background()
+		for _, book := range []Book{
+			{Title: "Emma", Author: "Jane Austen"},
+			{Title: "Dune", Author: "Frank Herbert"},
+			{Title: "Persuasion", Author: "Jane Austen"},
+			{Title: "100% Dune", Author: "Fan_Club"},
+			{Title: "Dune", Author: "Someone Else"},
+		} {
+			if err := store.CreateBook(ctx, &book); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tests := []struct {
+			name string
+			q    BookQuery
+			want string
+		}{
+			{"all by id", BookQuery{}, "[Emma Dune Persuasion 100% Dune Dune]"},
+			{"exact title", BookQuery{Title: "Dune"}, "[Dune Dune]"},
+			{"exact title is case-sensitive", BookQuery{Title: "dune"}, "[]"},
+			{"author prefix ignores case", BookQuery{AuthorPrefix: "jane"}, "[Emma Persuasion]"},
+			{"prefix wildcards are literal", BookQuery{TitlePrefix: "100%"}, "[100% Dune]"},
+			{"underscore is literal", BookQuery{AuthorPrefix: "Fan_"}, "[100% Dune]"},
+			{"percent alone matches nothing", BookQuery{TitlePrefix: "%"}, "[]"},
+			{"title descending, ties by id", BookQuery{SortBy: SortByTitle, Descending: true},
+				"[Persuasion Emma Dune Dune 100% Dune]"},
+			{"offset and limit", BookQuery{Offset: 1, Limit: 2}, "[Dune Persuasion]"},
+			{"after a cursor", BookQuery{SortBy: SortByTitle, After: &Cursor{ID: 2, Value: "Dune"}},
+				"[Dune Emma Persuasion]"},
+		}
+		for _, tt := range tests {
+			page, err := store.ListBooks(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got := titles(page); got != tt.want {
+				t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+			}
+		}
+
+		page, err := store.ListBooks(ctx, BookQuery{Author: "Jane Austen", Limit: 1, CountTotal: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !page.HasMore || page.Total != 2 || len(page.Books) != 1 {
+			t.Errorf("got %d books, total %d, has more %v; want 1 of 2 with more", len(page.Books), page.Total, page.HasMore)
+		}
+		if page, _ := store.ListBooks(ctx, BookQuery{Limit: 5}); page.HasMore {
+			t.Error("the last page reports more books")
+		}
+	})
+}
+
+func TestStorePrefixFiltersFoldASCIIOnly(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Interface) {
+		ctx := context.Background()
+		createBooks(t, store,
+			Book{Title: "Élan Vital", Author: "Henri Bergson"},
+			Book{Title: "ÉLAN", Author: "Zoë Anon"},
+		)
+
+		tests := []struct {
+			name string
+			q    BookQuery
+			want string
+		}{
+			{"ASCII case is ignored", BookQuery{AuthorPrefix: "HENRI"}, "[Élan Vital]"},
+			{"other case is not", BookQuery{TitlePrefix: "él"}, "[]"},
+			{"same case matches", BookQuery{TitlePrefix: "Éla"}, "[Élan Vital ÉLAN]"},
+			{"diacritics are not ignored", BookQuery{TitlePrefix: "Elan"}, "[]"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				page, err := store.ListBooks(ctx, tt.q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := titles(page); got != tt.want {
+					t.Errorf("got %s, want %s", got, tt.want)
+				}
+			})
+		}
+	})
+}
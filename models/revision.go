@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Actions recorded in the history of a book.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// BookRevision is an append-only record of a change to a book.
+type BookRevision struct {
+	ID     uint `json:"-" gorm:"primary_key"`
+	BookID uint `json:"book_id" gorm:"not null;uniqueIndex:idx_book_revisions_book_revision"`
+	// Revision numbers the changes of a book, starting at 1.
+	Revision uint   `json:"revision" gorm:"not null;uniqueIndex:idx_book_revisions_book_revision"`
+	Action   string `json:"action" gorm:"not null"`
+	Actor    string `json:"actor"`
+	TraceID  string `json:"trace_id"`
+	// Before and After are snapshots of the book around the change,
+	// Before is nil for the creation.
+	Before    *Book     `json:"before" gorm:"serializer:json"`
+	After     *Book     `json:"after" gorm:"serializer:json"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Markers delimiting matched terms in highlighted text before it is escaped.
+const (
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+// SearchQuery is a full-text search over book titles and authors.
+type SearchQuery struct {
+	// Terms are the folded words to look for, as returned by
+	// SearchTerms. A book matches if every term is a prefix of a word in
+	// its title or author, ignoring case and diacritics.
+	Terms []string
+	Limit int
+}
+
+// SearchResult is a book matching a SearchQuery.
+type SearchResult struct {
+	Book Book `json:"book"`
+	// Score ranks the results, higher is more relevant.
+	Score float64 `json:"score"`
+	// Highlights hold the title and author as HTML-escaped text with
+	// matched terms wrapped in <mark> elements.
+	Highlights Highlights `json:"highlights"`
+}
+
+// Highlights are the searched fields with matched terms marked.
+type Highlights struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// SearchTerms splits text into words usable in a SearchQuery, lower-cased
+// and without diacritics.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(foldSearch(text), isSeparator)
+}
+
+// foldSearch lower-cases s and removes its diacritics, as the
+// remove_diacritics option of the FTS5 tokenizer does, so that "elan"
+// matches "Élan".
+func foldSearch(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, strings.ToLower(s))
+	if err != nil {
+		return strings.ToLower(s)
+	}
+	return folded
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// searchBooks ranks books against the query in memory. Title matches weigh
+// more than author matches; results are ordered by score, then ID.
+func searchBooks(books []Book, q SearchQuery) []SearchResult {
+	results := []SearchResult{}
+	for _, book := range books {
+		if !ActiveBooks.includes(&book) {
+			continue
+		}
+		titleHits, titleMarked := markTerms(book.Title, q.Terms)
+		authorHits, authorMarked := markTerms(book.Author, q.Terms)
+
+		score := 0.0
+		matched := true
+		for _, term := range q.Terms {
+			t, a := titleHits[term], authorHits[term]
+			if t == 0 && a == 0 {
+				matched = false
+				break
+			}
+			score += 2*float64(t) + float64(a)
+		}
+		if !matched {
+			continue
+		}
+		results = append(results, SearchResult{
+			Book:  book,
+			Score: score,
+			Highlights: Highlights{
+				Title:  renderHighlight(titleMarked),
+				Author: renderHighlight(authorMarked),
+			},
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Book.ID < results[j].Book.ID
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return results
+}
+
+// markTerms wraps every word of text that starts with one of the terms in
+// highlight markers, and counts the matches per term.
+func markTerms(text string, terms []string) (map[string]int, string) {
+	hits := make(map[string]int)
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if isSeparator(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && !isSeparator(runes[j]) {
+			j++
+		}
+		word := string(runes[i:j])
+		folded := foldSearch(word)
+		matched := false
+		for _, term := range terms {
+			if strings.HasPrefix(folded, term) {
+				hits[term]++
+				matched = true
+			}
+		}
+		if matched {
+			b.WriteString(highlightStart + word + highlightEnd)
+		} else {
+			b.WriteString(word)
+		}
+		i = j
+	}
+	return hits, b.String()
+}
+
+// renderHighlight escapes marked text for HTML and turns the highlight
+// markers into <mark> elements.
+func renderHighlight(marked string) string {
+	return highlightReplacer.Replace(html.EscapeString(marked))
+}
+
+var highlightReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightEnd, "</mark>")
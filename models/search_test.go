@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func createBooks(t *testing.T, store Interface, books ...Book) {
+	t.Helper()
+	for _, book := range books {
+		if err := store.CreateBook(context.Background(), &book); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testSearch checks the search semantics every store shares.
+func testSearch(t *testing.T, store Interface) {
+	ctx := context.Background()
+	createBooks(t, store,
+		Book{Title: "The Dune Encyclopedia", Author: "Willis McNelly"},
+		Book{Title: "Children of Dune", Author: "Frank Herbert"},
+		Book{Title: "Emma", Author: "Jane Austen"},
+		Book{Title: "Dunes & <Deserts>", Author: "Dune Society"},
+	)
+
+	results, err := store.SearchBooks(ctx, SearchQuery{Terms: []string{"dun", "herb"}, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Book.Title != "Children of Dune" {
+		t.Fatalf("got %+v, want only the book matching both terms", results)
+	}
+	want := Highlights{Title: "Children of <mark>Dune</mark>", Author: "Frank <mark>Herbert</mark>"}
+	if results[0].Highlights != want {
+		t.Errorf("got highlights %+v, want %+v", results[0].Highlights, want)
+	}
+
+	results, err = store.SearchBooks(ctx, SearchQuery{Terms: []string{"deserts"}, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Highlights.Title != "Dunes &amp; &lt;<mark>Deserts</mark>&gt;" {
+		t.Errorf("got %+v, want the title HTML-escaped around the mark", results)
+	}
+
+	results, err = store.SearchBooks(ctx, SearchQuery{Terms: []string{"dune"}, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want the limit of 2", len(results))
+	}
+	if results[0].Score < results[1].Score {
+		t.Errorf("results are not ordered by score: %v then %v", results[0].Score, results[1].Score)
+	}
+	if results, _ := store.SearchBooks(ctx, SearchQuery{Terms: []string{"une"}, Limit: 10}); len(results) != 0 {
+		t.Errorf("got %+v, want terms to match word prefixes only", results)
+	}
+}
+
+// testSearchDiacritics checks that store ignores case and diacritics.
+func testSearchDiacritics(t *testing.T, store Interface) {
+	ctx := context.Background()
+	createBooks(t, store,
+		Book{Title: "Élan Vital", Author: "Henri Bergson"},
+		Book{Title: "Emma", Author: "Jane Austen"},
+	)
+	for _, q := range []string{"elan", "ÉLAN", "élan"} {
+		results, err := store.SearchBooks(ctx, SearchQuery{Terms: SearchTerms(q), Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].Book.Title != "Élan Vital" {
+			t.Errorf("%q: got %+v, want Élan Vital", q, results)
+			continue
+		}
+		if got, want := results[0].Highlights.Title, "<mark>Élan</mark> Vital"; got != want {
+			t.Errorf("%q: got highlight %q, want %q", q, got, want)
+		}
+	}
+}
+
+func TestSearchTermsFoldDiacritics(t *testing.T) {
+	got := SearchTerms("Élan Naïve ŒUVRE")
+	want := []string{"elan", "naive", "œuvre"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestMemoryStoreSearchBooks(t *testing.T) {
+	testSearch(t, NewMemoryStore())
+	testSearchDiacritics(t, NewMemoryStore())
+}
+
+func TestSQLStoreSearchBooksWithLike(t *testing.T) {
+	store := NewSQLStore(openTestDB(t))
+	store.fullText = false
+	testSearch(t, store)
+
+	// LIKE only ignores the case of ASCII letters, so diacritics must match
+	createBooks(t, store, Book{Title: "Élan", Author: "Anon"})
+	ctx := context.Background()
+	if results, _ := store.SearchBooks(ctx, SearchQuery{Terms: SearchTerms("elan"), Limit: 10}); len(results) != 0 {
+		t.Errorf("got %+v, want no match across diacritics", results)
+	}
+}
+
+func TestSQLStoreSearchBooksWithFullTextIndex(t *testing.T) {
+	db := openTestDB(t)
+	if !hasFullTextIndex(db) {
+		t.Skip("SQLite is built without FTS5, run the tests with -tags sqlite_fts5")
+	}
+	testSearch(t, NewSQLStore(db))
+	testSearchDiacritics(t, NewSQLStore(openTestDB(t)))
+}
+
+func TestSearchTerms(t *testing.T) {
+	got := SearchTerms("  Children-of DUNE, 2nd ed.")
+	want := []string{"children", "of", "dune", "2nd", "ed"}
+	if len(got) != len(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
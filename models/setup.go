@@ -1,6 +1,10 @@
 package models
 
 import (
+	"context"
+
+	"sample-app/pkg/migrate"
+
 	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -8,17 +12,74 @@ import (
 
 var DB *gorm.DB
 
-func ConnectDatabase() {
-	database, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{})
+// untraced shares the connections of DB without its tracing plugin, for
+// queries such as health checks that should not produce spans.
+var untraced *gorm.DB
+
+// schema checks the migrations of untraced. It is built once on connecting
+// rather than on every health check.
+var schema *migrate.Migrator
 
+// OpenDatabase opens the book database stored in the file at path without
+// migrating it.
+func OpenDatabase(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path), &gorm.Config{
+		// Report constraint violations as gorm.ErrDuplicatedKey and
+		// gorm.ErrForeignKeyViolated instead of raw driver errors.
+		TranslateError: true,
+	})
+}
+
+// ConnectDatabase opens the book database at path, applies the pending
+// migrations and sets DB. It returns the migrations it applied.
+func ConnectDatabase(ctx context.Context, path string) ([]migrate.Migration, error) {
+	database, err := OpenDatabase(path)
 	if err != nil {
-		panic("Failed to connect to database!")
+		return nil, err
+	}
+
+	migrator, err := NewMigrator(database)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return applied, err
+	}
+	// the index depends on how the binary was built, not on the schema
+	// version, so it is checked on every start
+	if err := SetupFullTextSearch(database); err != nil {
+		return applied, err
 	}
 
-	database.AutoMigrate(&Book{})
+	sqlDB, err := database.DB()
+	if err != nil {
+		return applied, err
+	}
+	untraced, err = gorm.Open(sqlite.Dialector{Conn: sqlDB}, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return applied, err
+	}
+	schema, err = NewMigrator(untraced)
+	if err != nil {
+		return applied, err
+	}
 
 	DB = database
 	if err := DB.Use(otelgorm.NewPlugin()); err != nil {
-		panic(err)
+		return applied, err
+	}
+	return applied, nil
+}
+
+// CloseDatabase closes the connections of DB.
+func CloseDatabase() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
 	}
+	return sqlDB.Close()
 }
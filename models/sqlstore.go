@@ -0,0 +1,528 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SQLStore implements Interface on top of a GORM database.
+type SQLStore struct {
+	db       *gorm.DB
+	fullText bool
+}
+
+var _ Interface = (*SQLStore)(nil)
+
+// NewSQLStore creates a store backed by the given database. Searches use
+// the FTS5 index when SetupFullTextSearch enabled it, and fall back to
+// ranking LIKE matches in memory otherwise. LIKE only ignores the case of
+// ASCII letters, so the fallback misses matches that differ in the case of
+// other letters or in diacritics.
+func NewSQLStore(db *gorm.DB) *SQLStore {
+	return &SQLStore{db: db, fullText: hasFullTextIndex(db)}
+}
+
+func (s *SQLStore) CreateBook(ctx context.Context, book *Book) error {
+	book.Version = 1
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkISBN(tx, book); err != nil {
+			return err
+		}
+		return tx.Create(book).Error
+	})
+}
+
+func (s *SQLStore) GetBook(ctx context.Context, id uint, visibility Visibility) (*Book, error) {
+	var book Book
+	db := s.db.WithContext(ctx)
+	if err := withVisibility(db, visibility).First(&book, id).Error; err != nil {
+		return nil, err
+	}
+	books := []Book{book}
+	if err := withRelations(db, books); err != nil {
+		return nil, err
+	}
+	return &books[0], nil
+}
+
+// withVisibility scopes db to books with the given deletion state.
+func withVisibility(db *gorm.DB, visibility Visibility) *gorm.DB {
+	switch visibility {
+	case DeletedBooks:
+		return db.Unscoped().Where("deleted_at IS NOT NULL")
+	case AllBooks:
+		return db.Unscoped()
+	}
+	return db
+}
+
+func (s *SQLStore) ListBooks(ctx context.Context, q BookQuery) (*BookPage, error) {
+	db := withVisibility(s.db.WithContext(ctx).Model(&Book{}), q.Visibility)
+	if q.Title != "" {
+		db = db.Where("title = ?", q.Title)
+	}
+	if q.TitlePrefix != "" {
+		db = db.Where("title LIKE ? ESCAPE '\\'", likePrefix(q.TitlePrefix))
+	}
+	if q.Author != "" {
+		db = db.Where("author = ?", q.Author)
+	}
+	if q.AuthorPrefix != "" {
+		db = db.Where("author LIKE ? ESCAPE '\\'", likePrefix(q.AuthorPrefix))
+	}
+	if q.AuthorID != 0 {
+		db = db.Where("id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", q.AuthorID)
+	}
+	if q.Genre != "" {
+		db = db.Where(hasTag, TagKindGenre, q.Genre)
+	}
+	if q.Tag != "" {
+		db = db.Where(hasTag, TagKindTag, q.Tag)
+	}
+
+	var page BookPage
+	if q.CountTotal {
+		if err := db.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	field := q.SortBy
+	if field == "" {
+		field = SortByID
+	}
+	cmp, dir := ">", "ASC"
+	if q.Descending {
+		cmp, dir = "<", "DESC"
+	}
+	if q.After != nil {
+		if field == SortByID {
+			db = db.Where("id "+cmp+" ?", q.After.ID)
+		} else {
+			col := string(field)
+			db = db.Where("("+col+" "+cmp+" ?) OR ("+col+" = ? AND id "+cmp+" ?)",
+				q.After.Value, q.After.Value, q.After.ID)
+		}
+	}
+	if field != SortByID {
+		db = db.Order(string(field) + " " + dir)
+	}
+	db = db.Order("id " + dir)
+	if q.Offset > 0 {
+		db = db.Offset(q.Offset)
+	}
+	if q.Limit > 0 {
+		// fetch one extra row to find out whether there is a next page
+		db = db.Limit(q.Limit + 1)
+	}
+
+	if err := db.Find(&page.Books).Error; err != nil {
+		return nil, err
+	}
+	if q.Limit > 0 && len(page.Books) > q.Limit {
+		page.Books = page.Books[:q.Limit]
+		page.HasMore = true
+	}
+	if err := withRelations(s.db.WithContext(ctx), page.Books); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (s *SQLStore) SearchBooks(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	if s.fullText {
+		return s.searchFTS(ctx, q)
+	}
+	db := s.db.WithContext(ctx)
+	for _, term := range q.Terms {
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+		db = db.Where("title LIKE ? ESCAPE '\\' OR author LIKE ? ESCAPE '\\'", pattern, pattern)
+	}
+	var books []Book
+	if err := db.Find(&books).Error; err != nil {
+		return nil, err
+	}
+	results := searchBooks(books, q)
+	if err := withResultRelations(s.db.WithContext(ctx), results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// hasTag matches the books linked to the tag of a kind and slug.
+const hasTag = "id IN (SELECT book_tags.book_id FROM book_tags JOIN tags ON tags.id = book_tags.tag_id WHERE tags.kind = ? AND tags.slug = ?)"
+
+// likePrefix escapes LIKE wildcards in prefix and appends a trailing wildcard.
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (s *SQLStore) UpdateBook(ctx context.Context, book *Book) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := checkVersion(tx, book.ID, book.Version)
+		if err != nil {
+			return err
+		}
+		if err := checkISBN(tx, book); err != nil {
+			return err
+		}
+		book.Version = current + 1
+		result := tx.Model(book).Where("version = ?", current).Select("*").Omit("deleted_at").Updates(book)
+		return versionRowsAffected(result)
+	})
+}
+
+func (s *SQLStore) DeleteBook(ctx context.Context, id uint, version uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := checkVersion(tx, id, version)
+		if err != nil {
+			return err
+		}
+		return versionRowsAffected(tx.Where("version = ?", current).Delete(&Book{}, id))
+	})
+}
+
+func (s *SQLStore) RestoreBook(ctx context.Context, id uint) (*Book, error) {
+	var book Book
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&Book{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.First(&book, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	books := []Book{book}
+	if err := withRelations(s.db.WithContext(ctx), books); err != nil {
+		return nil, err
+	}
+	return &books[0], nil
+}
+
+func (s *SQLStore) PurgeBooks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, link := range []any{&BookAuthor{}, &BookTag{}} {
+			err := tx.Where("book_id IN (SELECT id FROM books WHERE deleted_at IS NOT NULL AND deleted_at < ?)", deletedBefore).
+				Delete(link).Error
+			if err != nil {
+				return err
+			}
+		}
+		result := tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+			Delete(&Book{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+func (s *SQLStore) AddRevision(ctx context.Context, rev *BookRevision) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last uint
+		err := tx.Model(&BookRevision{}).Where("book_id = ?", rev.BookID).
+			Select("COALESCE(MAX(revision), 0)").Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		rev.Revision = last + 1
+		return tx.Create(rev).Error
+	})
+}
+
+func (s *SQLStore) ListRevisions(ctx context.Context, bookID uint) ([]BookRevision, error) {
+	var revs []BookRevision
+	err := s.db.WithContext(ctx).Where("book_id = ?", bookID).Order("revision").Find(&revs).Error
+	return revs, err
+}
+
+func (s *SQLStore) GetRevision(ctx context.Context, bookID uint, revision uint) (*BookRevision, error) {
+	var rev BookRevision
+	err := s.db.WithContext(ctx).Where("book_id = ? AND revision = ?", bookID, revision).First(&rev).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func (s *SQLStore) CreateAuthor(ctx context.Context, author *Author) error {
+	author.NameKey = AuthorKey(author.Name)
+	return s.db.WithContext(ctx).Create(author).Error
+}
+
+func (s *SQLStore) GetAuthor(ctx context.Context, id uint) (*Author, error) {
+	var author Author
+	if err := s.db.WithContext(ctx).First(&author, id).Error; err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+func (s *SQLStore) FirstOrCreateAuthor(ctx context.Context, author *Author) error {
+	author.NameKey = AuthorKey(author.Name)
+	return s.db.WithContext(ctx).Where(Author{NameKey: author.NameKey}).FirstOrCreate(author).Error
+}
+
+func (s *SQLStore) ListAuthors(ctx context.Context, q AuthorQuery) (*AuthorPage, error) {
+	db := s.db.WithContext(ctx).Order("name").Order("id")
+	if q.NamePrefix != "" {
+		db = db.Where("name LIKE ? ESCAPE '\\'", likePrefix(q.NamePrefix))
+	}
+	if q.Offset > 0 {
+		db = db.Offset(q.Offset)
+	}
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit + 1)
+	}
+
+	var page AuthorPage
+	if err := db.Find(&page.Authors).Error; err != nil {
+		return nil, err
+	}
+	if q.Limit > 0 && len(page.Authors) > q.Limit {
+		page.Authors = page.Authors[:q.Limit]
+		page.HasMore = true
+	}
+	return &page, nil
+}
+
+func (s *SQLStore) UpdateAuthor(ctx context.Context, author *Author) error {
+	author.NameKey = AuthorKey(author.Name)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(author).Select("name", "name_key", "updated_at").Updates(author)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.First(author, author.ID).Error
+	})
+}
+
+func (s *SQLStore) DeleteAuthor(ctx context.Context, id uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var books int64
+		if err := tx.Model(&BookAuthor{}).Where("author_id = ?", id).Count(&books).Error; err != nil {
+			return err
+		}
+		if books > 0 {
+			return ErrAuthorInUse
+		}
+		result := tx.Delete(&Author{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (s *SQLStore) SetBookAuthors(ctx context.Context, bookID uint, authorIDs []uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("book_id = ?", bookID).Delete(&BookAuthor{}).Error; err != nil {
+			return err
+		}
+		if len(authorIDs) == 0 {
+			return nil
+		}
+		links := make([]BookAuthor, len(authorIDs))
+		for i, id := range authorIDs {
+			links[i] = BookAuthor{BookID: bookID, AuthorID: id, Position: i}
+		}
+		return tx.Create(&links).Error
+	})
+}
+
+// authorsOf returns the authors of the given books in credit order, by book ID.
+func authorsOf(db *gorm.DB, bookIDs []uint) (map[uint][]Author, error) {
+	var links []BookAuthor
+	if err := db.Where("book_id IN ?", bookIDs).Order("book_id, position").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(links))
+	for i, link := range links {
+		ids[i] = link.AuthorID
+	}
+	var found []Author
+	if err := db.Find(&found, ids).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Author, len(found))
+	for _, author := range found {
+		byID[author.ID] = author
+	}
+
+	authors := make(map[uint][]Author)
+	for _, link := range links {
+		authors[link.BookID] = append(authors[link.BookID], byID[link.AuthorID])
+	}
+	return authors, nil
+}
+
+// tagsOf returns the genres and tags of the given books, by book ID.
+func tagsOf(db *gorm.DB, bookIDs []uint) (map[uint][]Tag, error) {
+	var links []BookTag
+	if err := db.Where("book_id IN ?", bookIDs).Order("book_id, position").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(links))
+	for i, link := range links {
+		ids[i] = link.TagID
+	}
+	var found []Tag
+	if err := db.Find(&found, ids).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]Tag, len(found))
+	for _, tag := range found {
+		byID[tag.ID] = tag
+	}
+
+	tags := make(map[uint][]Tag)
+	for _, link := range links {
+		tags[link.BookID] = append(tags[link.BookID], byID[link.TagID])
+	}
+	return tags, nil
+}
+
+// withRelations fills in the authors, genres and tags of books.
+func withRelations(db *gorm.DB, books []Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(books))
+	for i := range books {
+		ids[i] = books[i].ID
+	}
+	authors, err := authorsOf(db, ids)
+	if err != nil {
+		return err
+	}
+	tags, err := tagsOf(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range books {
+		books[i].Authors = authors[books[i].ID]
+		setBookTags(&books[i], tags[books[i].ID])
+	}
+	return nil
+}
+
+// withResultRelations fills in the authors, genres and tags of the books
+// found by a search.
+func withResultRelations(db *gorm.DB, results []SearchResult) error {
+	books := make([]Book, len(results))
+	for i := range results {
+		books[i] = results[i].Book
+	}
+	if err := withRelations(db, books); err != nil {
+		return err
+	}
+	for i := range results {
+		results[i].Book = books[i]
+	}
+	return nil
+}
+
+func (s *SQLStore) FirstOrCreateTag(ctx context.Context, tag *Tag) error {
+	tag.Slug = TagSlug(tag.Name)
+	return s.db.WithContext(ctx).Where(Tag{Kind: tag.Kind, Slug: tag.Slug}).FirstOrCreate(tag).Error
+}
+
+func (s *SQLStore) ListTags(ctx context.Context, kind string) ([]Tag, error) {
+	db := s.db.WithContext(ctx).Order("slug").Order("kind")
+	if kind != "" {
+		db = db.Where("kind = ?", kind)
+	}
+	var tags []Tag
+	err := db.Find(&tags).Error
+	return tags, err
+}
+
+func (s *SQLStore) SetBookTags(ctx context.Context, bookID uint, tagIDs []uint) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("book_id = ?", bookID).Delete(&BookTag{}).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		links := make([]BookTag, len(tagIDs))
+		for i, id := range tagIDs {
+			links[i] = BookTag{BookID: bookID, TagID: id, Position: i}
+		}
+		return tx.Create(&links).Error
+	})
+}
+
+// checkISBN fails with ErrDuplicateISBN if another book, deleted or not,
+// has the ISBN of book.
+func checkISBN(tx *gorm.DB, book *Book) error {
+	if book.ISBN == "" {
+		return nil
+	}
+	var count int64
+	err := tx.Unscoped().Model(&Book{}).Where("isbn = ? AND id <> ?", book.ISBN, book.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicateISBN
+	}
+	return nil
+}
+
+// checkVersion returns the stored version of a book, failing if it does
+// not match the expected non-zero version.
+func checkVersion(tx *gorm.DB, id uint, expected uint) (uint, error) {
+	var book Book
+	if err := tx.Select("version").First(&book, id).Error; err != nil {
+		return 0, err
+	}
+	if expected != 0 && expected != book.Version {
+		return 0, ErrVersionConflict
+	}
+	return book.Version, nil
+}
+
+// versionRowsAffected turns a versioned write that matched no rows, because
+// the book changed concurrently, into ErrVersionConflict.
+func versionRowsAffected(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// Transaction runs fn in a database transaction, which is committed if fn succeeds.
+func (s *SQLStore) Transaction(ctx context.Context, fn func(tx Interface) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&SQLStore{db: tx, fullText: s.fullText})
+	})
+}
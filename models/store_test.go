@@ -0,0 +1,96 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openTestDB creates an empty database with the schema of ConnectDatabase.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "books.db")), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetupFullTextSearch(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// forEachStore runs test against every Interface implementation, each
+// starting empty.
+func forEachStore(t *testing.T, test func(t *testing.T, store Interface)) {
+	t.Run("sql", func(t *testing.T) {
+		test(t, NewSQLStore(openTestDB(t)))
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStore())
+	})
+}
+
+func TestStoreCRUD(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Interface) {
+		ctx := context.Background()
+		first := &Book{Title: "Dune", Author: "Frank Herbert"}
+		second := &Book{Title: "Emma", Author: "Jane Austen"}
+		for _, book := range []*Book{first, second} {
+			if err := store.CreateBook(ctx, book); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if first.ID == 0 || second.ID == first.ID {
+			t.Fatalf("got ids %d and %d, want distinct ids", first.ID, second.ID)
+		}
+
+		first.Title = "Dune Messiah"
+		if err := store.UpdateBook(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		got, err := store.GetBook(ctx, first.ID, ActiveBooks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != "Dune Messiah" || got.Author != "Frank Herbert" {
+			t.Errorf("got %+v after the update", got)
+		}
+
+		if err := store.DeleteBook(ctx, second.ID, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.GetBook(ctx, second.ID, ActiveBooks); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("got %v for a deleted book, want gorm.ErrRecordNotFound", err)
+		}
+		page, err := store.ListBooks(ctx, BookQuery{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Books) != 1 || page.Books[0].ID != first.ID {
+			t.Errorf("got books %+v, want only book %d", page.Books, first.ID)
+		}
+	})
+}
+
+func TestStoreReportsMissingBooks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Interface) {
+		ctx := context.Background()
+		if err := store.UpdateBook(ctx, &Book{ID: 42, Title: "Dune"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("update: got %v, want gorm.ErrRecordNotFound", err)
+		}
+		if err := store.DeleteBook(ctx, 42, 0); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("delete: got %v, want gorm.ErrRecordNotFound", err)
+		}
+	})
+}
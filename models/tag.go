@@ -0,0 +1,52 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Kinds of tags. Genres classify the books of the catalog, free-form tags
+// describe anything else.
+const (
+	TagKindGenre = "genre"
+	TagKindTag   = "tag"
+)
+
+// Tag is an entry of the catalog taxonomy. Tags are identified by their
+// kind and slug, so "Science Fiction" and "science-fiction" are the same
+// genre.
+type Tag struct {
+	ID   uint   `json:"id" gorm:"primary_key"`
+	Kind string `json:"kind" gorm:"not null;uniqueIndex:idx_tags_kind_slug"`
+	Name string `json:"name" gorm:"not null"`
+	Slug string `json:"slug" gorm:"not null;uniqueIndex:idx_tags_kind_slug"`
+}
+
+// BookTag links a book to one of its genres or tags.
+type BookTag struct {
+	BookID   uint `gorm:"primaryKey;autoIncrement:false"`
+	TagID    uint `gorm:"primaryKey;autoIncrement:false;index"`
+	Position int  `gorm:"not null"`
+}
+
+// TagSlug returns the slug identifying a tag name: its lowercased words
+// joined by hyphens.
+func TagSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// setBookTags fills in the genres and tags of a book from its linked tags.
+func setBookTags(book *Book, tags []Tag) {
+	book.Genres, book.Tags = nil, nil
+	for _, tag := range tags {
+		switch tag.Kind {
+		case TagKindGenre:
+			book.Genres = append(book.Genres, tag.Name)
+		case TagKindTag:
+			book.Tags = append(book.Tags, tag.Name)
+		}
+	}
+}
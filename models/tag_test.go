@@ -0,0 +1,114 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTagSlug(t *testing.T) {
+	tests := map[string]string{
+		"Science Fiction":   "science-fiction",
+		" science-fiction ": "science-fiction",
+		"Sci-Fi & Fantasy!": "sci-fi-fantasy",
+		"Ciência":           "ciência",
+		"--":                "",
+	}
+	for name, want := range tests {
+		if got := TagSlug(name); got != want {
+			t.Errorf("TagSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestStoreTags(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Interface) {
+		ctx := context.Background()
+		scifi := Tag{Kind: TagKindGenre, Name: "Science Fiction"}
+		again := Tag{Kind: TagKindGenre, Name: "science-fiction"}
+		classic := Tag{Kind: TagKindTag, Name: "Classic"}
+		for _, tag := range []*Tag{&scifi, &again, &classic} {
+			if err := store.FirstOrCreateTag(ctx, tag); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if again.ID != scifi.ID || again.Name != "Science Fiction" {
+			t.Errorf("got %+v, want the existing genre %+v", again, scifi)
+		}
+
+		dune := &Book{Title: "Dune", Author: "Frank Herbert"}
+		emma := &Book{Title: "Emma", Author: "Jane Austen"}
+		createBooks(t, store, *dune, *emma)
+		if err := store.SetBookTags(ctx, 1, []uint{classic.ID, scifi.ID}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetBookTags(ctx, 2, []uint{classic.ID}); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := store.GetBook(ctx, 1, ActiveBooks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Genres) != 1 || got.Genres[0] != "Science Fiction" || len(got.Tags) != 1 || got.Tags[0] != "Classic" {
+			t.Errorf("got genres %q and tags %q", got.Genres, got.Tags)
+		}
+
+		for _, tt := range []struct {
+			q    BookQuery
+			want string
+		}{
+			{BookQuery{Genre: "science-fiction"}, "[Dune]"},
+			{BookQuery{Tag: "classic"}, "[Dune Emma]"},
+			{BookQuery{Tag: "classic", Genre: "romance"}, "[]"},
+		} {
+			page, err := store.ListBooks(ctx, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(page); got != tt.want {
+				t.Errorf("%+v: got %s, want %s", tt.q, got, tt.want)
+			}
+		}
+
+		genres, err := store.ListTags(ctx, TagKindGenre)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(genres) != 1 || genres[0].Slug != "science-fiction" {
+			t.Errorf("got genres %+v, want science-fiction only", genres)
+		}
+	})
+}
+
+func TestStoreRejectsDuplicateISBN(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Interface) {
+		ctx := context.Background()
+		dune := &Book{Title: "Dune", Author: "Frank Herbert", ISBN: "9780441172719"}
+		if err := store.CreateBook(ctx, dune); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.CreateBook(ctx, &Book{Title: "Emma", Author: "Jane Austen"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.CreateBook(ctx, &Book{Title: "Persuasion", Author: "Jane Austen"}); err != nil {
+			t.Errorf("books without an ISBN conflict: %v", err)
+		}
+		if err := store.DeleteBook(ctx, dune.ID, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		copied := &Book{Title: "Dune (copy)", Author: "Frank Herbert", ISBN: "9780441172719"}
+		if err := store.CreateBook(ctx, copied); !errors.Is(err, ErrDuplicateISBN) {
+			t.Errorf("create: got %v, want ErrDuplicateISBN for the ISBN of a book in the trash", err)
+		}
+		emma, err := store.GetBook(ctx, 2, ActiveBooks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emma.ISBN = "9780441172719"
+		if err := store.UpdateBook(ctx, emma); !errors.Is(err, ErrDuplicateISBN) {
+			t.Errorf("update: got %v, want ErrDuplicateISBN", err)
+		}
+	})
+}
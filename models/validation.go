@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// Length limits for book fields, counted in characters.
+const (
+	MaxTitleLength       = 255
+	MaxAuthorLength      = 255
+	MaxPublisherLength   = 255
+	MaxDescriptionLength = 5000
+	MaxTagLength         = 64
+)
+
+// FieldError describes why a single field is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a value.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Normalize trims surrounding whitespace from the book fields.
+func (b *Book) Normalize() {
+	b.Title = strings.TrimSpace(b.Title)
+	b.Author = strings.TrimSpace(b.Author)
+	for i := range b.Authors {
+		b.Authors[i].Normalize()
+	}
+	b.ISBN = NormalizeISBN(b.ISBN)
+	b.Publisher = strings.TrimSpace(b.Publisher)
+	b.Language = strings.TrimSpace(b.Language)
+	if tag, err := language.Parse(b.Language); err == nil {
+		b.Language = tag.String()
+	}
+	b.Description = strings.TrimSpace(b.Description)
+	for i := range b.Genres {
+		b.Genres[i] = strings.TrimSpace(b.Genres[i])
+	}
+	for i := range b.Tags {
+		b.Tags[i] = strings.TrimSpace(b.Tags[i])
+	}
+}
+
+// Validate checks the book fields, returning a ValidationError if any is invalid.
+// Books should be normalized first. The byline may be left empty when the
+// authors are listed, each by ID or name.
+func (b *Book) Validate() error {
+	var errs ValidationError
+	errs.checkText("title", b.Title, MaxTitleLength)
+	if b.Author != "" || len(b.Authors) == 0 {
+		errs.checkText("author", b.Author, MaxAuthorLength)
+	}
+	for i, author := range b.Authors {
+		if author.ID == 0 {
+			errs.checkAuthorName(fmt.Sprintf("authors[%d].name", i), author.Name)
+		}
+	}
+	if b.ISBN != "" && !ValidISBN(b.ISBN) {
+		errs.add("isbn", "must be a valid ISBN-10 or ISBN-13")
+	}
+	errs.checkLength("publisher", b.Publisher, MaxPublisherLength)
+	if maxYear := time.Now().Year() + 1; b.PublicationYear < 0 || b.PublicationYear > maxYear {
+		errs.add("publication_year", fmt.Sprintf("must be between 0 and %d", maxYear))
+	}
+	if b.Pages < 0 {
+		errs.add("pages", "must not be negative")
+	}
+	if b.Language != "" {
+		if _, err := language.Parse(b.Language); err != nil {
+			errs.add("language", "must be a language tag such as en or pt-BR")
+		}
+	}
+	errs.checkLength("description", b.Description, MaxDescriptionLength)
+	for i, name := range b.Genres {
+		errs.checkTagName(fmt.Sprintf("genres[%d]", i), name)
+	}
+	for i, name := range b.Tags {
+		errs.checkTagName(fmt.Sprintf("tags[%d]", i), name)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Normalize trims surrounding whitespace from the author name.
+func (a *Author) Normalize() {
+	a.Name = strings.TrimSpace(a.Name)
+}
+
+// Validate checks the author fields, returning a ValidationError if any is
+// invalid. Authors should be normalized first.
+func (a *Author) Validate() error {
+	var errs ValidationError
+	errs.checkAuthorName("name", a.Name)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (e *ValidationError) checkTagName(field, name string) {
+	n := len(*e)
+	e.checkText(field, name, MaxTagLength)
+	if len(*e) == n && TagSlug(name) == "" {
+		e.add(field, "must contain a letter or digit")
+	}
+}
+
+func (e *ValidationError) checkAuthorName(field, name string) {
+	n := len(*e)
+	e.checkText(field, name, MaxAuthorLength)
+	if len(*e) == n && AuthorKey(name) == "" {
+		e.add(field, "must contain a letter or digit")
+	}
+}
+
+func (e *ValidationError) checkText(field, value string, maxLength int) {
+	switch {
+	case value == "":
+		e.add(field, "is required")
+	case !utf8.ValidString(value):
+		e.add(field, "must be valid UTF-8")
+	case utf8.RuneCountInString(value) > maxLength:
+		e.add(field, fmt.Sprintf("must be at most %d characters long", maxLength))
+	}
+}
+
+// checkLength checks an optional text field.
+func (e *ValidationError) checkLength(field, value string, maxLength int) {
+	if value != "" {
+		e.checkText(field, value, maxLength)
+	}
+}
+
+func (e *ValidationError) add(field, message string) {
+	*e = append(*e, FieldError{Field: field, Message: message})
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBookValidate(t *testing.T) {
+	long := strings.Repeat("é", MaxTitleLength+1)
+	tests := []struct {
+		name string
+		book Book
+		want ValidationError
+	}{
+		{"valid", Book{Title: "Dune", Author: "Frank Herbert"}, nil},
+		{"limit counts characters", Book{Title: long[:2*MaxTitleLength], Author: "X"}, nil},
+		{"missing fields", Book{}, ValidationError{
+			{Field: "title", Message: "is required"},
+			{Field: "author", Message: "is required"},
+		}},
+		{"too long", Book{Title: long, Author: "X"}, ValidationError{
+			{Field: "title", Message: "must be at most 255 characters long"},
+		}},
+		{"invalid UTF-8", Book{Title: "Dune", Author: "\xff"}, ValidationError{
+			{Field: "author", Message: "must be valid UTF-8"},
+		}},
+	}
+	for _, tt := range tests {
+		err := tt.book.Validate()
+		var got ValidationError
+		if err != nil && !errors.As(err, &got) {
+			t.Fatalf("%s: got %v, want a ValidationError", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBookNormalize(t *testing.T) {
+	book := Book{Title: "  Dune\n", Author: "\tFrank Herbert "}
+	book.Normalize()
+	if book.Title != "Dune" || book.Author != "Frank Herbert" {
+		t.Errorf("got %q by %q", book.Title, book.Author)
+	}
+	book = Book{Title: "   ", Author: "X"}
+	book.Normalize()
+	if err := book.Validate(); err == nil {
+		t.Error("a blank title is accepted")
+	}
+}
+
+func TestBookValidateCatalogData(t *testing.T) {
+	book := Book{
+		Title:           "Dune",
+		Author:          "Frank Herbert",
+		ISBN:            "978-0-441-17271-0",
+		PublicationYear: -1,
+		Pages:           -3,
+		Language:        "not a language!",
+		Genres:          []string{"Science Fiction", "!!"},
+		Tags:            []string{""},
+	}
+	book.Normalize()
+	var errs ValidationError
+	if !errors.As(book.Validate(), &errs) {
+		t.Fatal("expected a ValidationError")
+	}
+	var fields []string
+	for _, fe := range errs {
+		fields = append(fields, fe.Field)
+	}
+	want := []string{"isbn", "publication_year", "pages", "language", "genres[1]", "tags[0]"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got invalid fields %q, want %q", fields, want)
+	}
+}
+
+func TestBookNormalizeCatalogData(t *testing.T) {
+	book := Book{ISBN: "0-441-17271-7", Language: "PT-br", Genres: []string{" Horror "}}
+	book.Normalize()
+	if book.ISBN != "9780441172719" || book.Language != "pt-BR" || book.Genres[0] != "Horror" {
+		t.Errorf("got ISBN %q, language %q and genres %q", book.ISBN, book.Language, book.Genres)
+	}
+}
@@ -0,0 +1,328 @@
+// Package jsonpatch applies RFC 7396 JSON Merge Patch and RFC 6902 JSON Patch
+// documents to JSON values.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types of the supported patch formats.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned when a patch document is malformed.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed is returned when a JSON Patch "test" operation does not match.
+	ErrTestFailed = errors.New("test operation failed")
+)
+
+// MergePatch is a parsed RFC 7396 JSON Merge Patch document.
+type MergePatch struct {
+	patch any
+}
+
+// ParseMergePatch parses a JSON Merge Patch document.
+func ParseMergePatch(data []byte) (*MergePatch, error) {
+	var patch any
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return &MergePatch{patch: patch}, nil
+}
+
+// Apply merges the patch into the JSON document doc.
+func (p *MergePatch) Apply(doc []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(target, p.patch))
+}
+
+func mergeValue(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergeValue(t[k], v)
+		}
+	}
+	return t
+}
+
+// Operation is a single RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+
+	path  []string
+	from  []string
+	value any
+}
+
+// Patch is a parsed RFC 6902 JSON Patch document.
+type Patch []Operation
+
+// ParsePatch parses and validates a JSON Patch document.
+func ParsePatch(data []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i := range patch {
+		if err := patch[i].parse(); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+	}
+	return patch, nil
+}
+
+func (op *Operation) parse() error {
+	var err error
+	if op.path, err = parsePointer(op.Path); err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return fmt.Errorf("%q operation requires a value", op.Op)
+		}
+		return json.Unmarshal(op.Value, &op.value)
+	case "move", "copy":
+		if op.from, err = parsePointer(op.From); err != nil {
+			return err
+		}
+		if op.Op == "move" && isProperPrefix(op.from, op.path) {
+			return fmt.Errorf("cannot move %q into one of its children", op.From)
+		}
+		return nil
+	case "remove":
+		return nil
+	}
+	return fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// Apply applies the operations in order to the JSON document doc.
+// Either all operations succeed or an error is returned.
+func (p Patch) Apply(doc []byte) ([]byte, error) {
+	var root any
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, err
+	}
+	for i := range p {
+		var err error
+		if root, err = p[i].apply(root); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, p[i].Op, p[i].Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+func (op *Operation) apply(root any) (any, error) {
+	switch op.Op {
+	case "add":
+		return add(root, op.path, deepCopy(op.value))
+	case "remove":
+		root, _, err := remove(root, op.path)
+		return root, err
+	case "replace":
+		if len(op.path) == 0 {
+			// RFC 6902 allows replacing the whole document
+			return deepCopy(op.value), nil
+		}
+		root, _, err := remove(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, deepCopy(op.value))
+	case "move":
+		root, value, err := remove(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, value)
+	case "copy":
+		value, err := get(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, deepCopy(value))
+	case "test":
+		value, err := get(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, op.value) {
+			return nil, ErrTestFailed
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with '/'", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isProperPrefix(prefix, tokens []string) bool {
+	return len(prefix) < len(tokens) && reflect.DeepEqual(prefix, tokens[:len(prefix)])
+}
+
+func get(node any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q does not exist", token)
+			}
+			node = child
+		case []any:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot index into a scalar with %q", token)
+		}
+	}
+	return node, nil
+}
+
+// add inserts value at tokens and returns the updated node.
+func add(node any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	token, last := tokens[0], len(tokens) == 1
+	switch n := node.(type) {
+	case map[string]any:
+		if last {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q does not exist", token)
+		}
+		child, err := add(child, tokens[1:], value)
+		n[token] = child
+		return n, err
+	case []any:
+		if last {
+			i := len(n)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		n[i], err = add(n[i], tokens[1:], value)
+		return n, err
+	}
+	return nil, fmt.Errorf("cannot add %q to a scalar", token)
+}
+
+// remove deletes the value at tokens and returns the updated node and the removed value.
+func remove(node any, tokens []string) (any, any, error) {
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("cannot remove the document root")
+	}
+	token, last := tokens[0], len(tokens) == 1
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q does not exist", token)
+		}
+		if last {
+			delete(n, token)
+			return n, child, nil
+		}
+		child, removed, err := remove(child, tokens[1:])
+		n[token] = child
+		return n, removed, err
+	case []any:
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+		child, removed, err := remove(n[i], tokens[1:])
+		n[i] = child
+		return n, removed, err
+	}
+	return nil, nil, fmt.Errorf("cannot remove %q from a scalar", token)
+}
+
+// arrayIndex parses an array index token, which must be within [0, max].
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for k, child := range v {
+			c[k] = deepCopy(child)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, child := range v {
+			c[i] = deepCopy(child)
+		}
+		return c
+	}
+	return value
+}
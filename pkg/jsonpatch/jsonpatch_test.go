@@ -0,0 +1,163 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// assertJSONEqual compares JSON documents regardless of key order.
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid JSON %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove member", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"nested", `{"a":{"b":"c","d":"e"}}`, `{"a":{"d":null,"f":"g"}}`, `{"a":{"b":"c","f":"g"}}`},
+		{"array replaced", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{"non object patch", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"object into scalar", `{"a":"b"}`, `{"a":{"c":null,"d":1}}`, `{"a":{"d":1}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParseMergePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := patch.Apply([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestParseMergePatchInvalid(t *testing.T) {
+	if _, err := ParseMergePatch([]byte(`{`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("got %v, want ErrInvalidPatch", err)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"append to array", `{"foo":[1]}`, `[{"op":"add","path":"/foo/-","value":2}]`, `{"foo":[1,2]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"replace member", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"replace root", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":{"baz":"qux"}}]`, `{"baz":"qux"}`},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"copy member", `{"foo":{"a":[1]}}`, `[{"op":"copy","from":"/foo","path":"/bar"},{"op":"add","path":"/bar/a/-","value":2}]`,
+			`{"foo":{"a":[1]},"bar":{"a":[1,2]}}`},
+		{"test then replace", `{"v":1}`, `[{"op":"test","path":"/v","value":1},{"op":"replace","path":"/v","value":2}]`, `{"v":2}`},
+		{"escaped pointer", `{"a/b":1,"m~n":2}`, `[{"op":"remove","path":"/a~1b"},{"op":"remove","path":"/m~0n"}]`, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParsePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := patch.Apply([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSONEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestParsePatchInvalid(t *testing.T) {
+	tests := map[string]string{
+		"not an array":       `{"op":"add"}`,
+		"unknown operation":  `[{"op":"frobnicate","path":"/a"}]`,
+		"missing value":      `[{"op":"add","path":"/a"}]`,
+		"relative pointer":   `[{"op":"remove","path":"a"}]`,
+		"move into children": `[{"op":"move","from":"/a","path":"/a/b"}]`,
+	}
+	for name, patch := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParsePatch([]byte(patch)); !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("got %v, want ErrInvalidPatch", err)
+			}
+		})
+	}
+}
+
+func TestPatchApplyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+	}{
+		{"remove missing member", `{}`, `[{"op":"remove","path":"/a"}]`},
+		{"remove root", `{}`, `[{"op":"remove","path":""}]`},
+		{"replace missing member", `{}`, `[{"op":"replace","path":"/a","value":1}]`},
+		{"add to missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`},
+		{"array index out of bounds", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`},
+		{"leading zero index", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ParsePatch([]byte(tt.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := patch.Apply([]byte(tt.doc)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestPatchTestFailed(t *testing.T) {
+	patch, err := ParsePatch([]byte(`[{"op":"test","path":"/v","value":2}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := patch.Apply([]byte(`{"v":1}`)); !errors.Is(err, ErrTestFailed) {
+		t.Errorf("got %v, want ErrTestFailed", err)
+	}
+}
+
+func TestPatchDoesNotAliasValues(t *testing.T) {
+	patch, err := ParsePatch([]byte(`[{"op":"add","path":"/a","value":{"n":1}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := patch.Apply([]byte(`{}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertJSONEqual(t, got, `{"a":{"n":1}}`)
+	}
+}
@@ -0,0 +1,153 @@
+// Package expvar implements metrics.Factory with variables published by the
+// standard expvar package, which serves them as JSON at /debug/vars.
+//
+// Metrics are published under their namespaced name followed by their
+// tags, as in "book-service.requests|endpoint=/books|error=false".
+package expvar
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"sample-app/pkg/metrics"
+)
+
+// DefaultSampleSize is the number of recent observations timers and
+// histograms compute their percentiles from.
+const DefaultSampleSize = 1028
+
+// publishMu makes looking up and publishing a variable atomic, since
+// expvar panics when a name is published twice.
+var publishMu sync.Mutex
+
+// Factory implements metrics.Factory with expvar variables.
+type Factory struct {
+	scope      string
+	tags       map[string]string
+	sampleSize int
+}
+
+// NewFactory creates a Factory whose timers and histograms keep the last
+// sampleSize observations, DefaultSampleSize if zero.
+func NewFactory(sampleSize int) *Factory {
+	if sampleSize <= 0 {
+		sampleSize = DefaultSampleSize
+	}
+	return &Factory{sampleSize: sampleSize}
+}
+
+// Counter implements Counter of metrics.Factory.
+func (f *Factory) Counter(options metrics.Options) metrics.Counter {
+	return publish(f.key(options.Name, options.Tags), func() *counter {
+		return &counter{}
+	})
+}
+
+// Gauge implements Gauge of metrics.Factory.
+func (f *Factory) Gauge(options metrics.Options) metrics.Gauge {
+	return publish(f.key(options.Name, options.Tags), func() *gauge {
+		return &gauge{}
+	})
+}
+
+// Timer implements Timer of metrics.Factory. Its percentiles are in
+// seconds. Buckets are ignored.
+func (f *Factory) Timer(options metrics.TimerOptions) metrics.Timer {
+	return publish(f.key(options.Name, options.Tags), func() *timer {
+		return &timer{sample: newSample(f.sampleSize)}
+	})
+}
+
+// Histogram implements Histogram of metrics.Factory. Buckets are ignored.
+func (f *Factory) Histogram(options metrics.HistogramOptions) metrics.Histogram {
+	return publish(f.key(options.Name, options.Tags), func() *histogram {
+		return &histogram{sample: newSample(f.sampleSize)}
+	})
+}
+
+// Namespace implements Namespace of metrics.Factory.
+func (f *Factory) Namespace(scope metrics.NSOptions) metrics.Factory {
+	return &Factory{
+		scope:      f.subScope(scope.Name),
+		tags:       f.mergeTags(scope.Tags),
+		sampleSize: f.sampleSize,
+	}
+}
+
+// publish returns the variable published under key, publishing the one
+// made by newVar if there is none. It panics if the variable published
+// under key is of another kind.
+func publish[V expvar.Var](key string, newVar func() V) V {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	if existing := expvar.Get(key); existing != nil {
+		v, ok := existing.(V)
+		if !ok {
+			panic(fmt.Sprintf("expvar: metric %q is already published as a %T", key, existing))
+		}
+		return v
+	}
+	v := newVar()
+	expvar.Publish(key, v)
+	return v
+}
+
+func (f *Factory) key(name string, tags map[string]string) string {
+	return metrics.GetKey(f.subScope(name), f.mergeTags(tags), "|", "=")
+}
+
+func (f *Factory) subScope(name string) string {
+	if f.scope == "" {
+		return name
+	}
+	if name == "" {
+		return f.scope
+	}
+	return f.scope + "." + name
+}
+
+func (f *Factory) mergeTags(tags map[string]string) map[string]string {
+	ret := make(map[string]string, len(f.tags)+len(tags))
+	for k, v := range f.tags {
+		ret[k] = v
+	}
+	for k, v := range tags {
+		ret[k] = v
+	}
+	return ret
+}
+
+type counter struct {
+	expvar.Int
+}
+
+func (c *counter) Inc(delta int64) {
+	c.Add(delta)
+}
+
+type gauge struct {
+	expvar.Int
+}
+
+func (g *gauge) Update(v int64) {
+	g.Set(v)
+}
+
+type timer struct {
+	*sample
+}
+
+func (t *timer) Record(d time.Duration) {
+	t.observe(d.Seconds())
+}
+
+type histogram struct {
+	*sample
+}
+
+func (h *histogram) Record(v float64) {
+	h.observe(v)
+}
@@ -0,0 +1,64 @@
+package expvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"sample-app/pkg/metrics"
+)
+
+func TestFactoryPublishesTaggedKeys(t *testing.T) {
+	f := NewFactory(0).Namespace(metrics.NSOptions{Name: "test-keys", Tags: map[string]string{"service": "books"}})
+	f.Counter(metrics.Options{Name: "requests", Tags: map[string]string{"error": "false"}}).Inc(2)
+	f.Gauge(metrics.Options{Name: "queue"}).Update(7)
+
+	for key, want := range map[string]string{
+		"test-keys.requests|error=false|service=books": "2",
+		"test-keys.queue|service=books":                "7",
+	} {
+		v := expvar.Get(key)
+		if v == nil {
+			t.Errorf("%s is not published", key)
+			continue
+		}
+		if got := v.String(); got != want {
+			t.Errorf("%s: got %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestFactoryReusesPublishedMetrics(t *testing.T) {
+	f := NewFactory(0)
+	f.Counter(metrics.Options{Name: "test-reuse.requests"}).Inc(1)
+	f.Counter(metrics.Options{Name: "test-reuse.requests"}).Inc(1)
+	if got := expvar.Get("test-reuse.requests").String(); got != "2" {
+		t.Errorf("got %s, want both counters to share a value", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic publishing a gauge under the name of a counter")
+		}
+	}()
+	f.Gauge(metrics.Options{Name: "test-reuse.requests"})
+}
+
+func TestTimerSummary(t *testing.T) {
+	timer := NewFactory(0).Timer(metrics.TimerOptions{Name: "test-timer.latency"})
+	for _, d := range []time.Duration{time.Second, 3 * time.Second, 2 * time.Second} {
+		timer.Record(d)
+	}
+
+	var summary map[string]float64
+	if err := json.Unmarshal([]byte(expvar.Get("test-timer.latency").String()), &summary); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"count": 3, "sum": 6, "min": 1, "max": 3, "p50": 2, "p75": 3, "p90": 3, "p95": 3, "p99": 3}
+	for k, v := range want {
+		if summary[k] != v {
+			t.Errorf("%s: got %v, want %v", k, summary[k], v)
+		}
+	}
+}
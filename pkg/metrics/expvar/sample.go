@@ -0,0 +1,99 @@
+package expvar
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+)
+
+// percentiles are the quantiles published for timers and histograms.
+var percentiles = []struct {
+	name string
+	q    float64
+}{
+	{"p50", 0.50},
+	{"p75", 0.75},
+	{"p90", 0.90},
+	{"p95", 0.95},
+	{"p99", 0.99},
+}
+
+// sample summarizes a distribution of observations. Count, sum, min and
+// max cover every observation, percentiles the most recent ones.
+type sample struct {
+	mu     sync.Mutex
+	values []float64 // ring buffer of the recent observations
+	next   int
+	count  int64
+	sum    float64
+	min    float64
+	max    float64
+}
+
+func newSample(size int) *sample {
+	return &sample{values: make([]float64, 0, size)}
+}
+
+func (s *sample) observe(v float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.values) < cap(s.values) {
+		s.values = append(s.values, v)
+	} else {
+		s.values[s.next] = v
+		s.next = (s.next + 1) % len(s.values)
+	}
+	if s.count == 0 || v < s.min {
+		s.min = v
+	}
+	if s.count == 0 || v > s.max {
+		s.max = v
+	}
+	s.count++
+	s.sum += v
+}
+
+// String implements expvar.Var, rendering the sample as a JSON object.
+// Values JSON cannot represent, NaN and infinities, are rendered as null
+// so that /debug/vars stays valid.
+func (s *sample) String() string {
+	s.mu.Lock()
+	sorted := append([]float64(nil), s.values...)
+	summary := map[string]any{
+		"count": s.count,
+		"sum":   jsonFloat(s.sum),
+		"min":   jsonFloat(s.min),
+		"max":   jsonFloat(s.max),
+	}
+	s.mu.Unlock()
+
+	sort.Float64s(sorted)
+	for _, p := range percentiles {
+		summary[p.name] = jsonFloat(quantile(sorted, p.q))
+	}
+	b, err := json.Marshal(summary)
+	if err != nil {
+		return "null"
+	}
+	return string(b)
+}
+
+// jsonFloat returns v, or nil if it is not finite.
+func jsonFloat(v float64) any {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return v
+}
+
+// quantile returns the q-quantile of sorted values with the nearest rank
+// method, 0 if there are none.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
@@ -0,0 +1,63 @@
+package expvar
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4}
+	tests := []struct {
+		q    float64
+		want float64
+	}{
+		{0, 1},
+		{0.25, 1},
+		{0.5, 2},
+		{0.75, 3},
+		{0.99, 4},
+		{1, 4},
+	}
+	for _, tt := range tests {
+		if got := quantile(sorted, tt.q); got != tt.want {
+			t.Errorf("quantile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+	if got := quantile(nil, 0.5); got != 0 {
+		t.Errorf("got %v without values, want 0", got)
+	}
+}
+
+func TestSampleKeepsRecentValues(t *testing.T) {
+	s := newSample(2)
+	for _, v := range []float64{10, 1, 2} {
+		s.observe(v)
+	}
+	var summary map[string]float64
+	if err := json.Unmarshal([]byte(s.String()), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary["count"] != 3 || summary["max"] != 10 || summary["p99"] != 2 {
+		t.Errorf("got %v, want every value counted and percentiles of the last two", summary)
+	}
+}
+
+func TestSampleRendersNonFiniteValues(t *testing.T) {
+	s := newSample(4)
+	s.observe(math.Inf(1))
+	s.observe(math.NaN())
+	s.observe(1)
+
+	out := s.String()
+	var summary map[string]*float64
+	if err := json.Unmarshal([]byte(out), &summary); err != nil {
+		t.Fatalf("got invalid JSON %q: %v", out, err)
+	}
+	if summary["max"] != nil || summary["sum"] != nil {
+		t.Errorf("got %s, want infinite max and NaN sum rendered as null", out)
+	}
+	if summary["count"] == nil || *summary["count"] != 3 {
+		t.Errorf("got %s, want a count of 3", out)
+	}
+}
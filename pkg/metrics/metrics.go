@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // MustInit initializes the passed in metrics and initializes its fields using the passed in factory.
@@ -16,9 +17,25 @@ import (
 // It uses reflection to initialize a struct containing metrics fields
 // by assigning new Counter/Gauge/Timer values with the metric name retrieved
 // from the `metric` tag and stats tags retrieved from the `tags` tag.
+// Timers and Histograms take their buckets from the `buckets` tag, a comma
+// separated list of durations such as "5ms,10ms,100ms,1s" for Timers and of
+// numbers for Histograms, sorted in increasing order.
 //
-// Note: all fields of the struct must be exported, have a `metric` tag, and be
-// of type Counter or Gauge or Timer.
+// Fields holding a struct, or a pointer to one, are initialized recursively.
+// Their `metric` tag, if any, prefixes the names of the nested metrics as a
+// namespace, and their `tags` are added to those of the nested metrics.
+//
+// Fields of type map[string]M, with M one of the metric types or a pointer
+// to a struct, hold one metric per value of a tag: the `key` tag names the
+// tag and the `values` tag lists its values, as in
+//
+//	Requests map[string]Counter `metric:"requests" key:"status" values:"2xx,3xx,4xx,5xx"`
+//
+// Fields tagged `metric:"-"` are skipped. Slice and array fields are not
+// supported, since their metrics could not be told apart: use a map instead.
+//
+// Note: all other fields of the struct must be exported, have a `metric` tag,
+// and be of type Counter or Gauge or Timer or Histogram.
 //
 // Errors during Init lead to a panic.
 func MustInit(metrics any, factory Factory, globalTags map[string]string) {
@@ -35,95 +52,250 @@ func Init(m any, factory Factory, globalTags map[string]string) error {
 		factory = NullFactory
 	}
 
-	counterPtrType := reflect.TypeOf((*Counter)(nil)).Elem()
-	gaugePtrType := reflect.TypeOf((*Gauge)(nil)).Elem()
-	timerPtrType := reflect.TypeOf((*Timer)(nil)).Elem()
-	histogramPtrType := reflect.TypeOf((*Histogram)(nil)).Elem()
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Init expects a pointer to a struct, got %T", m)
+	}
+	return initStruct(v.Elem(), "", factory, globalTags)
+}
+
+var (
+	counterPtrType   = reflect.TypeOf((*Counter)(nil)).Elem()
+	gaugePtrType     = reflect.TypeOf((*Gauge)(nil)).Elem()
+	timerPtrType     = reflect.TypeOf((*Timer)(nil)).Elem()
+	histogramPtrType = reflect.TypeOf((*Histogram)(nil)).Elem()
+)
 
-	v := reflect.ValueOf(m).Elem()
+// initStruct initializes the fields of the struct v. path is the name of
+// the field holding v, used in errors.
+func initStruct(v reflect.Value, path string, factory Factory, globalTags map[string]string) error {
 	t := v.Type()
 	for i := 0; i < t.NumField(); i++ {
-		tags := make(map[string]string)
-		for k, v := range globalTags {
-			tags[k] = v
-		}
-		var buckets []float64
 		field := t.Field(i)
+		name := field.Name
+		if path != "" {
+			name = path + "." + field.Name
+		}
 		metric := field.Tag.Get("metric")
-		if metric == "" {
-			return fmt.Errorf("Field %s is missing a tag 'metric'", field.Name)
+		if metric == "-" {
+			continue
 		}
-		if tagString := field.Tag.Get("tags"); tagString != "" {
-			tagPairs := strings.Split(tagString, ",")
-			for _, tagPair := range tagPairs {
-				tag := strings.Split(tagPair, "=")
-				if len(tag) != 2 {
-					return fmt.Errorf(
-						"Field [%s]: Tag [%s] is not of the form key=value in 'tags' string [%s]",
-						field.Name, tagPair, tagString)
-				}
-				tags[tag[0]] = tag[1]
+		if !field.IsExported() {
+			return fmt.Errorf("Field %s is unexported, tag it with metric:\"-\" to skip it", name)
+		}
+		fieldTags, err := parseTags(name, field.Tag.Get("tags"))
+		if err != nil {
+			return err
+		}
+
+		fv := v.Field(i)
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			err = initStruct(fv, name, namespace(factory, metric, fieldTags), globalTags)
+		case isStructPointer(field.Type):
+			if fv.IsNil() {
+				fv.Set(reflect.New(field.Type.Elem()))
+			}
+			err = initStruct(fv.Elem(), name, namespace(factory, metric, fieldTags), globalTags)
+		case field.Type.Kind() == reflect.Map:
+			err = initMap(fv, name, field, factory, fieldTags, globalTags)
+		case field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Array:
+			err = fmt.Errorf("Field %s is a slice or array, which is not supported, use a map with 'key' and 'values' tags instead", name)
+		default:
+			if metric == "" {
+				return fmt.Errorf("Field %s is missing a tag 'metric'", name)
+			}
+			var obj reflect.Value
+			obj, err = newMetric(name, field, field.Type, factory, mergeTags(globalTags, fieldTags))
+			if err == nil {
+				fv.Set(obj)
 			}
 		}
-		if bucketString := field.Tag.Get("buckets"); bucketString != "" {
-			switch {
-			case field.Type.AssignableTo(timerPtrType):
-				// TODO: Parse timer duration buckets
-				return fmt.Errorf(
-					"Field [%s]: Buckets are not currently initialized for timer metrics",
-					field.Name)
-			case field.Type.AssignableTo(histogramPtrType):
-				bucketValues := strings.Split(bucketString, ",")
-				for _, bucket := range bucketValues {
-					b, err := strconv.ParseFloat(bucket, 64)
-					if err != nil {
-						return fmt.Errorf(
-							"Field [%s]: Bucket [%s] could not be converted to float64 in 'buckets' string [%s]",
-							field.Name, bucket, bucketString)
-					}
-					buckets = append(buckets, b)
-				}
-			default:
-				return fmt.Errorf(
-					"Field [%s]: Buckets should only be defined for Timer and Histogram metric types",
-					field.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initMap fills the map field fv with a metric, or a struct of metrics,
+// for every value of the tag named by the `key` tag of field.
+func initMap(
+	fv reflect.Value,
+	name string,
+	field reflect.StructField,
+	factory Factory,
+	fieldTags map[string]string,
+	globalTags map[string]string,
+) error {
+	metric := field.Tag.Get("metric")
+	key := field.Tag.Get("key")
+	valueString := field.Tag.Get("values")
+	if field.Type.Key().Kind() != reflect.String {
+		return fmt.Errorf("Field %s is a map whose keys are not strings", name)
+	}
+	if key == "" || valueString == "" {
+		return fmt.Errorf("Field %s is a map but is missing a tag 'key' or 'values'", name)
+	}
+	elemType := field.Type.Elem()
+	if !isStructPointer(elemType) && metric == "" {
+		return fmt.Errorf("Field %s is missing a tag 'metric'", name)
+	}
+
+	m := reflect.MakeMap(field.Type)
+	for _, value := range strings.Split(valueString, ",") {
+		value = strings.TrimSpace(value)
+		if m.MapIndex(reflect.ValueOf(value).Convert(field.Type.Key())).IsValid() {
+			return fmt.Errorf("Field [%s]: Value [%s] is repeated in 'values' string [%s]", name, value, valueString)
+		}
+		tags := mergeTags(fieldTags, map[string]string{key: value})
+		elemName := fmt.Sprintf("%s[%s]", name, value)
+
+		var elem reflect.Value
+		if isStructPointer(elemType) {
+			elem = reflect.New(elemType.Elem())
+			if err := initStruct(elem.Elem(), elemName, namespace(factory, metric, tags), globalTags); err != nil {
+				return err
+			}
+		} else {
+			var err error
+			elem, err = newMetric(elemName, field, elemType, factory, mergeTags(globalTags, tags))
+			if err != nil {
+				return err
 			}
 		}
-		help := field.Tag.Get("help")
-		var obj any
+		m.SetMapIndex(reflect.ValueOf(value).Convert(field.Type.Key()), elem)
+	}
+	fv.Set(m)
+	return nil
+}
+
+// newMetric creates the metric of type typ described by the tags of field.
+func newMetric(
+	name string,
+	field reflect.StructField,
+	typ reflect.Type,
+	factory Factory,
+	tags map[string]string,
+) (reflect.Value, error) {
+	var buckets []float64
+	var timerBuckets []time.Duration
+	metric := field.Tag.Get("metric")
+	if bucketString := field.Tag.Get("buckets"); bucketString != "" {
 		switch {
-		case field.Type.AssignableTo(counterPtrType):
-			obj = factory.Counter(Options{
-				Name: metric,
-				Tags: tags,
-				Help: help,
-			})
-		case field.Type.AssignableTo(gaugePtrType):
-			obj = factory.Gauge(Options{
-				Name: metric,
-				Tags: tags,
-				Help: help,
-			})
-		case field.Type.AssignableTo(timerPtrType):
-			// TODO: Add buckets once parsed (see TODO above)
-			obj = factory.Timer(TimerOptions{
-				Name: metric,
-				Tags: tags,
-				Help: help,
-			})
-		case field.Type.AssignableTo(histogramPtrType):
-			obj = factory.Histogram(HistogramOptions{
-				Name:    metric,
-				Tags:    tags,
-				Help:    help,
-				Buckets: buckets,
-			})
+		case typ.AssignableTo(timerPtrType):
+			bucketValues := strings.Split(bucketString, ",")
+			for _, bucket := range bucketValues {
+				b, err := time.ParseDuration(strings.TrimSpace(bucket))
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf(
+						"Field [%s]: Bucket [%s] could not be converted to time.Duration in 'buckets' string [%s]",
+						name, bucket, bucketString)
+				}
+				if n := len(timerBuckets); n > 0 && b <= timerBuckets[n-1] {
+					return reflect.Value{}, fmt.Errorf(
+						"Field [%s]: Buckets must be sorted in increasing order and unique in 'buckets' string [%s]",
+						name, bucketString)
+				}
+				timerBuckets = append(timerBuckets, b)
+			}
+		case typ.AssignableTo(histogramPtrType):
+			bucketValues := strings.Split(bucketString, ",")
+			for _, bucket := range bucketValues {
+				b, err := strconv.ParseFloat(strings.TrimSpace(bucket), 64)
+				if err != nil {
+					return reflect.Value{}, fmt.Errorf(
+						"Field [%s]: Bucket [%s] could not be converted to float64 in 'buckets' string [%s]",
+						name, bucket, bucketString)
+				}
+				if n := len(buckets); n > 0 && b <= buckets[n-1] {
+					return reflect.Value{}, fmt.Errorf(
+						"Field [%s]: Buckets must be sorted in increasing order and unique in 'buckets' string [%s]",
+						name, bucketString)
+				}
+				buckets = append(buckets, b)
+			}
 		default:
-			return fmt.Errorf(
-				"Field %s is not a pointer to timer, gauge, or counter",
-				field.Name)
+			return reflect.Value{}, fmt.Errorf(
+				"Field [%s]: Buckets should only be defined for Timer and Histogram metric types",
+				name)
 		}
-		v.Field(i).Set(reflect.ValueOf(obj))
 	}
-	return nil
+	help := field.Tag.Get("help")
+	var obj any
+	switch {
+	case typ.AssignableTo(counterPtrType):
+		obj = factory.Counter(Options{
+			Name: metric,
+			Tags: tags,
+			Help: help,
+		})
+	case typ.AssignableTo(gaugePtrType):
+		obj = factory.Gauge(Options{
+			Name: metric,
+			Tags: tags,
+			Help: help,
+		})
+	case typ.AssignableTo(timerPtrType):
+		obj = factory.Timer(TimerOptions{
+			Name:    metric,
+			Tags:    tags,
+			Help:    help,
+			Buckets: timerBuckets,
+		})
+	case typ.AssignableTo(histogramPtrType):
+		obj = factory.Histogram(HistogramOptions{
+			Name:    metric,
+			Tags:    tags,
+			Help:    help,
+			Buckets: buckets,
+		})
+	default:
+		return reflect.Value{}, fmt.Errorf(
+			"Field %s is not a pointer to timer, gauge, or counter",
+			name)
+	}
+	return reflect.ValueOf(obj), nil
+}
+
+// parseTags parses a `tags` string of comma separated key=value pairs.
+func parseTags(name, tagString string) (map[string]string, error) {
+	tags := make(map[string]string)
+	if tagString == "" {
+		return tags, nil
+	}
+	for _, tagPair := range strings.Split(tagString, ",") {
+		tag := strings.Split(tagPair, "=")
+		if len(tag) != 2 {
+			return nil, fmt.Errorf(
+				"Field [%s]: Tag [%s] is not of the form key=value in 'tags' string [%s]",
+				name, tagPair, tagString)
+		}
+		tags[tag[0]] = tag[1]
+	}
+	return tags, nil
+}
+
+// namespace returns the factory of the metrics nested in a field, scoped
+// by its metric name and tags if it has any.
+func namespace(factory Factory, metric string, tags map[string]string) Factory {
+	if metric == "" && len(tags) == 0 {
+		return factory
+	}
+	return factory.Namespace(NSOptions{Name: metric, Tags: tags})
+}
+
+func mergeTags(tags, overrides map[string]string) map[string]string {
+	ret := make(map[string]string, len(tags)+len(overrides))
+	for k, v := range tags {
+		ret[k] = v
+	}
+	for k, v := range overrides {
+		ret[k] = v
+	}
+	return ret
+}
+
+func isStructPointer(t reflect.Type) bool {
+	return t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct
 }
\ No newline at end of file
@@ -0,0 +1,324 @@
+package metrics_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"sample-app/pkg/metrics"
+	"sample-app/pkg/metrics/metricstest"
+)
+
+// bucketsFactory records the buckets of the timers and histograms it creates.
+type bucketsFactory struct {
+	metrics.Factory
+	timerBuckets     map[string][]time.Duration
+	histogramBuckets map[string][]float64
+}
+
+func newBucketsFactory() *bucketsFactory {
+	return &bucketsFactory{
+		Factory:          metrics.NullFactory,
+		timerBuckets:     make(map[string][]time.Duration),
+		histogramBuckets: make(map[string][]float64),
+	}
+}
+
+func (f *bucketsFactory) Timer(options metrics.TimerOptions) metrics.Timer {
+	f.timerBuckets[options.Name] = options.Buckets
+	return metrics.NullTimer
+}
+
+func (f *bucketsFactory) Histogram(options metrics.HistogramOptions) metrics.Histogram {
+	f.histogramBuckets[options.Name] = options.Buckets
+	return metrics.NullHistogram
+}
+
+func TestInit(t *testing.T) {
+	var m struct {
+		Requests metrics.Counter   `metric:"requests" tags:"error=false"`
+		InFlight metrics.Gauge     `metric:"in_flight"`
+		Latency  metrics.Timer     `metric:"latency"`
+		Size     metrics.Histogram `metric:"size"`
+	}
+	f := metricstest.NewFactory()
+	if err := metrics.Init(&m, f, map[string]string{"service": "books"}); err != nil {
+		t.Fatal(err)
+	}
+	m.Requests.Inc(1)
+	m.InFlight.Update(2)
+	m.Latency.Record(time.Second)
+	m.Size.Record(3)
+
+	f.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "requests", Tags: map[string]string{"service": "books", "error": "false"}, Value: 1,
+	})
+	f.AssertGaugeMetrics(t, metricstest.ExpectedMetric{
+		Name: "in_flight", Tags: map[string]string{"service": "books"}, Value: 2,
+	})
+	f.AssertTimerMetrics(t, metricstest.ExpectedMetric{
+		Name: "latency", Tags: map[string]string{"service": "books"}, Value: 1,
+	})
+	f.AssertHistogramMetrics(t, metricstest.ExpectedMetric{
+		Name: "size", Tags: map[string]string{"service": "books"}, Value: 1,
+	})
+}
+
+func TestInitNilFactory(t *testing.T) {
+	var m struct {
+		Requests metrics.Counter `metric:"requests"`
+	}
+	if err := metrics.Init(&m, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	m.Requests.Inc(1)
+}
+
+func TestInitBuckets(t *testing.T) {
+	var m struct {
+		Latency metrics.Timer     `metric:"latency" buckets:"1ms, 10ms,1s"`
+		Size    metrics.Histogram `metric:"size" buckets:"0.5,1,10"`
+		Default metrics.Timer     `metric:"default"`
+	}
+	f := newBucketsFactory()
+	if err := metrics.Init(&m, f, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(f.timerBuckets["latency"]), "[1ms 10ms 1s]"; got != want {
+		t.Errorf("timer buckets = %s, want %s", got, want)
+	}
+	if got, want := fmt.Sprint(f.histogramBuckets["size"]), "[0.5 1 10]"; got != want {
+		t.Errorf("histogram buckets = %s, want %s", got, want)
+	}
+	if got := f.timerBuckets["default"]; got != nil {
+		t.Errorf("timer without buckets got %v, want nil", got)
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics any
+		err     string
+	}{
+		{
+			name: "missing metric tag",
+			metrics: &struct {
+				Requests metrics.Counter
+			}{},
+			err: "Field Requests is missing a tag 'metric'",
+		},
+		{
+			name: "malformed tags",
+			metrics: &struct {
+				Requests metrics.Counter `metric:"requests" tags:"error"`
+			}{},
+			err: "Tag [error] is not of the form key=value",
+		},
+		{
+			name: "unsupported type",
+			metrics: &struct {
+				Requests int `metric:"requests"`
+			}{},
+			err: "Field Requests is not a pointer to timer, gauge, or counter",
+		},
+		{
+			name: "invalid timer bucket",
+			metrics: &struct {
+				Latency metrics.Timer `metric:"latency" buckets:"1ms,fast"`
+			}{},
+			err: "Bucket [fast] could not be converted to time.Duration",
+		},
+		{
+			name: "unsorted timer buckets",
+			metrics: &struct {
+				Latency metrics.Timer `metric:"latency" buckets:"10ms,1ms"`
+			}{},
+			err: "Buckets must be sorted in increasing order and unique",
+		},
+		{
+			name: "repeated histogram buckets",
+			metrics: &struct {
+				Size metrics.Histogram `metric:"size" buckets:"1,1"`
+			}{},
+			err: "Buckets must be sorted in increasing order and unique",
+		},
+		{
+			name: "invalid histogram bucket",
+			metrics: &struct {
+				Size metrics.Histogram `metric:"size" buckets:"1,big"`
+			}{},
+			err: "Bucket [big] could not be converted to float64",
+		},
+		{
+			name: "buckets on a counter",
+			metrics: &struct {
+				Requests metrics.Counter `metric:"requests" buckets:"1"`
+			}{},
+			err: "Buckets should only be defined for Timer and Histogram metric types",
+		},
+		{
+			name:    "not a pointer to a struct",
+			metrics: struct{}{},
+			err:     "Init expects a pointer to a struct",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := metrics.Init(tt.metrics, metrics.NullFactory, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMustInitPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustInit did not panic")
+		}
+	}()
+	metrics.MustInit(&struct {
+		Requests metrics.Counter
+	}{}, nil, nil)
+}
+
+type cacheMetrics struct {
+	Hits   metrics.Counter `metric:"hits"`
+	Misses metrics.Counter `metric:"misses" tags:"reason=expired"`
+}
+
+func TestInitNested(t *testing.T) {
+	var m struct {
+		Cache    cacheMetrics    `metric:"cache" tags:"layer=l1"`
+		Inline   cacheMetrics    // no prefix
+		Ptr      *cacheMetrics   `metric:"ptr"`
+		Skipped  metrics.Counter `metric:"-"`
+		internal int             `metric:"-"`
+	}
+	f := metricstest.NewFactory()
+	if err := metrics.Init(&m, f, map[string]string{"service": "books"}); err != nil {
+		t.Fatal(err)
+	}
+	m.Cache.Hits.Inc(1)
+	m.Inline.Hits.Inc(2)
+	m.Ptr.Misses.Inc(3)
+
+	f.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "cache.hits", Tags: map[string]string{"service": "books", "layer": "l1"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "cache.misses", Tags: map[string]string{"service": "books", "layer": "l1", "reason": "expired"}, Value: 0},
+		metricstest.ExpectedMetric{Name: "hits", Tags: map[string]string{"service": "books"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "ptr.misses", Tags: map[string]string{"service": "books", "reason": "expired"}, Value: 3},
+	)
+	if m.Skipped != nil {
+		t.Error("field tagged metric:\"-\" was set")
+	}
+}
+
+func TestInitMaps(t *testing.T) {
+	var m struct {
+		Requests map[string]metrics.Counter `metric:"requests" key:"status" values:"2xx, 5xx" tags:"kind=http"`
+		Latency  map[string]metrics.Timer   `metric:"latency" key:"op" values:"get,put" buckets:"1ms,10ms"`
+		ByPeer   map[string]*cacheMetrics   `metric:"peer" key:"peer" values:"db,api"`
+	}
+	f := metricstest.NewFactory()
+	if err := metrics.Init(&m, f, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Requests) != 2 || len(m.Latency) != 2 || len(m.ByPeer) != 2 {
+		t.Fatalf("got maps %v %v %v, want 2 entries each", m.Requests, m.Latency, m.ByPeer)
+	}
+	m.Requests["5xx"].Inc(1)
+	m.Latency["put"].Record(time.Millisecond)
+	m.ByPeer["db"].Hits.Inc(2)
+
+	f.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"kind": "http", "status": "5xx"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"kind": "http", "status": "2xx"}, Value: 0},
+		metricstest.ExpectedMetric{Name: "peer.hits", Tags: map[string]string{"peer": "db"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "peer.misses", Tags: map[string]string{"peer": "api", "reason": "expired"}, Value: 0},
+	)
+	f.AssertTimerMetrics(t,
+		metricstest.ExpectedMetric{Name: "latency", Tags: map[string]string{"op": "put"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "latency", Tags: map[string]string{"op": "get"}, Value: 0},
+	)
+}
+
+func TestInitNestedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics any
+		err     string
+	}{
+		{
+			name: "unexported field",
+			metrics: &struct {
+				requests metrics.Counter `metric:"requests"`
+			}{},
+			err: `Field requests is unexported, tag it with metric:"-" to skip it`,
+		},
+		{
+			name: "error in nested struct",
+			metrics: &struct {
+				Cache struct {
+					Hits metrics.Counter
+				} `metric:"cache"`
+			}{},
+			err: "Field Cache.Hits is missing a tag 'metric'",
+		},
+		{
+			name: "map without values",
+			metrics: &struct {
+				Requests map[string]metrics.Counter `metric:"requests" key:"status"`
+			}{},
+			err: "Field Requests is a map but is missing a tag 'key' or 'values'",
+		},
+		{
+			name: "map with repeated values",
+			metrics: &struct {
+				Requests map[string]metrics.Counter `metric:"requests" key:"status" values:"2xx,2xx"`
+			}{},
+			err: "Value [2xx] is repeated",
+		},
+		{
+			name: "map without string keys",
+			metrics: &struct {
+				Requests map[int]metrics.Counter `metric:"requests" key:"status" values:"1"`
+			}{},
+			err: "Field Requests is a map whose keys are not strings",
+		},
+		{
+			name: "slice",
+			metrics: &struct {
+				Requests []metrics.Counter `metric:"requests"`
+			}{},
+			err: "Field Requests is a slice or array, which is not supported",
+		},
+		{
+			name: "array in nested struct",
+			metrics: &struct {
+				Cache struct {
+					Hits [2]metrics.Counter `metric:"hits"`
+				}
+			}{},
+			err: "Field Cache.Hits is a slice or array, which is not supported",
+		},
+		{
+			name: "error in map entry",
+			metrics: &struct {
+				Latency map[string]metrics.Timer `metric:"latency" key:"op" values:"get" buckets:"fast"`
+			}{},
+			err: "Field [Latency[get]]: Bucket [fast]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := metrics.Init(tt.metrics, metrics.NullFactory, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
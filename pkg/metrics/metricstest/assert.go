@@ -0,0 +1,61 @@
+package metricstest
+
+import (
+	"testing"
+)
+
+// ExpectedMetric is a metric a test expects to have been recorded. For
+// timers and histograms, Value is the number of observations.
+type ExpectedMetric struct {
+	Name  string
+	Tags  map[string]string
+	Value int64
+}
+
+// AssertCounterMetrics checks that the counters have the expected values.
+func (f *Factory) AssertCounterMetrics(t testing.TB, expected ...ExpectedMetric) {
+	t.Helper()
+	assertMetrics(t, "counter", f.Snapshot().Counters, expected)
+}
+
+// AssertGaugeMetrics checks that the gauges have the expected values.
+func (f *Factory) AssertGaugeMetrics(t testing.TB, expected ...ExpectedMetric) {
+	t.Helper()
+	assertMetrics(t, "gauge", f.Snapshot().Gauges, expected)
+}
+
+// AssertTimerMetrics checks that the timers recorded the expected number
+// of observations.
+func (f *Factory) AssertTimerMetrics(t testing.TB, expected ...ExpectedMetric) {
+	t.Helper()
+	counts := make(map[string]int64)
+	for k, v := range f.Snapshot().Timers {
+		counts[k] = int64(len(v))
+	}
+	assertMetrics(t, "timer", counts, expected)
+}
+
+// AssertHistogramMetrics checks that the histograms recorded the expected
+// number of observations.
+func (f *Factory) AssertHistogramMetrics(t testing.TB, expected ...ExpectedMetric) {
+	t.Helper()
+	counts := make(map[string]int64)
+	for k, v := range f.Snapshot().Histograms {
+		counts[k] = int64(len(v))
+	}
+	assertMetrics(t, "histogram", counts, expected)
+}
+
+func assertMetrics(t testing.TB, kind string, actual map[string]int64, expected []ExpectedMetric) {
+	t.Helper()
+	for _, e := range expected {
+		key := GetKey(e.Name, e.Tags)
+		v, ok := actual[key]
+		switch {
+		case !ok:
+			t.Errorf("%s %s was not created", kind, key)
+		case v != e.Value:
+			t.Errorf("%s %s = %d, want %d", kind, key, v, e.Value)
+		}
+	}
+}
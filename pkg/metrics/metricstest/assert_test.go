@@ -0,0 +1,54 @@
+package metricstest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sample-app/pkg/metrics"
+)
+
+// recorder is a testing.TB collecting the errors reported to it.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertMetrics(t *testing.T) {
+	f := NewFactory()
+	tags := map[string]string{"endpoint": "/books"}
+	f.Counter(metrics.Options{Name: "requests", Tags: tags}).Inc(2)
+	f.Gauge(metrics.Options{Name: "in_flight"}).Update(1)
+	f.Timer(metrics.TimerOptions{Name: "latency", Tags: tags}).Record(time.Millisecond)
+	f.Histogram(metrics.HistogramOptions{Name: "size"}).Record(1)
+	f.Histogram(metrics.HistogramOptions{Name: "size"}).Record(2)
+
+	f.AssertCounterMetrics(t, ExpectedMetric{Name: "requests", Tags: tags, Value: 2})
+	f.AssertGaugeMetrics(t, ExpectedMetric{Name: "in_flight", Value: 1})
+	f.AssertTimerMetrics(t, ExpectedMetric{Name: "latency", Tags: tags, Value: 1})
+	f.AssertHistogramMetrics(t, ExpectedMetric{Name: "size", Value: 2})
+}
+
+func TestAssertMetricsReportsMismatches(t *testing.T) {
+	f := NewFactory()
+	f.Counter(metrics.Options{Name: "requests"}).Inc(1)
+
+	r := &recorder{TB: t}
+	f.AssertCounterMetrics(r,
+		ExpectedMetric{Name: "requests", Value: 2},
+		ExpectedMetric{Name: "missing", Value: 0},
+	)
+	want := []string{
+		"counter requests = 1, want 2",
+		"counter missing was not created",
+	}
+	if fmt.Sprint(r.errors) != fmt.Sprint(want) {
+		t.Errorf("got errors %q, want %q", r.errors, want)
+	}
+}
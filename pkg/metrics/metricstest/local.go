@@ -0,0 +1,240 @@
+// Package metricstest provides a metrics.Factory that keeps metrics in
+// memory, so tests can check what code under test recorded.
+package metricstest
+
+import (
+	"sync"
+	"time"
+
+	"sample-app/pkg/metrics"
+)
+
+// Backend holds the metrics recorded by the factories sharing it. It is
+// safe for concurrent use.
+type Backend struct {
+	mu         sync.Mutex
+	counters   map[string]int64
+	gauges     map[string]int64
+	timers     map[string][]time.Duration
+	histograms map[string][]float64
+}
+
+// Snapshot is a copy of the metrics of a Backend, keyed by GetKey.
+type Snapshot struct {
+	Counters   map[string]int64
+	Gauges     map[string]int64
+	Timers     map[string][]time.Duration
+	Histograms map[string][]float64
+}
+
+func newBackend() *Backend {
+	return &Backend{
+		counters:   make(map[string]int64),
+		gauges:     make(map[string]int64),
+		timers:     make(map[string][]time.Duration),
+		histograms: make(map[string][]float64),
+	}
+}
+
+// Snapshot returns the current value of every counter and gauge and the
+// observations of every timer and histogram.
+func (b *Backend) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := Snapshot{
+		Counters:   make(map[string]int64, len(b.counters)),
+		Gauges:     make(map[string]int64, len(b.gauges)),
+		Timers:     make(map[string][]time.Duration, len(b.timers)),
+		Histograms: make(map[string][]float64, len(b.histograms)),
+	}
+	for k, v := range b.counters {
+		s.Counters[k] = v
+	}
+	for k, v := range b.gauges {
+		s.Gauges[k] = v
+	}
+	for k, v := range b.timers {
+		s.Timers[k] = append([]time.Duration(nil), v...)
+	}
+	for k, v := range b.histograms {
+		s.Histograms[k] = append([]float64(nil), v...)
+	}
+	return s
+}
+
+// Reset zeroes every counter and gauge and forgets the observations of
+// every timer and histogram. The metrics stay registered, so they still
+// appear in snapshots, as metrics created once by the code under test would
+// in a real backend.
+func (b *Backend) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for k := range b.counters {
+		b.counters[k] = 0
+	}
+	for k := range b.gauges {
+		b.gauges[k] = 0
+	}
+	for k := range b.timers {
+		b.timers[k] = nil
+	}
+	for k := range b.histograms {
+		b.histograms[k] = nil
+	}
+}
+
+func (b *Backend) incCounter(key string, delta int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.counters[key] += delta
+}
+
+func (b *Backend) updateGauge(key string, value int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gauges[key] = value
+}
+
+func (b *Backend) recordTimer(key string, d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.timers[key] = append(b.timers[key], d)
+}
+
+func (b *Backend) recordHistogram(key string, v float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.histograms[key] = append(b.histograms[key], v)
+}
+
+// register makes a metric appear in snapshots before it is first updated,
+// like in backends that export metrics as soon as they are created.
+func register[V any](b *Backend, metrics *map[string]V, key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := (*metrics)[key]; !ok {
+		var zero V
+		(*metrics)[key] = zero
+	}
+}
+
+// Factory is a metrics.Factory recording metrics in memory. Metric names
+// are prefixed with the names of the enclosing namespaces, separated by
+// dots.
+type Factory struct {
+	*Backend
+	namespace string
+	tags      map[string]string
+}
+
+// NewFactory creates a Factory with an empty Backend.
+func NewFactory() *Factory {
+	return &Factory{Backend: newBackend()}
+}
+
+// GetKey returns the key of a metric in snapshots: its name followed by
+// its tags in key order, as in "requests|endpoint=/books|error=false".
+func GetKey(name string, tags map[string]string) string {
+	return metrics.GetKey(name, tags, "|", "=")
+}
+
+// Counter implements Counter of metrics.Factory.
+func (f *Factory) Counter(options metrics.Options) metrics.Counter {
+	key := f.key(options.Name, options.Tags)
+	register(f.Backend, &f.counters, key)
+	return &localCounter{backend: f.Backend, key: key}
+}
+
+// Gauge implements Gauge of metrics.Factory.
+func (f *Factory) Gauge(options metrics.Options) metrics.Gauge {
+	key := f.key(options.Name, options.Tags)
+	register(f.Backend, &f.gauges, key)
+	return &localGauge{backend: f.Backend, key: key}
+}
+
+// Timer implements Timer of metrics.Factory.
+func (f *Factory) Timer(options metrics.TimerOptions) metrics.Timer {
+	key := f.key(options.Name, options.Tags)
+	register(f.Backend, &f.timers, key)
+	return &localTimer{backend: f.Backend, key: key}
+}
+
+// Histogram implements Histogram of metrics.Factory.
+func (f *Factory) Histogram(options metrics.HistogramOptions) metrics.Histogram {
+	key := f.key(options.Name, options.Tags)
+	register(f.Backend, &f.histograms, key)
+	return &localHistogram{backend: f.Backend, key: key}
+}
+
+// Namespace implements Namespace of metrics.Factory. The nested factory
+// shares the Backend of f.
+func (f *Factory) Namespace(scope metrics.NSOptions) metrics.Factory {
+	return &Factory{
+		Backend:   f.Backend,
+		namespace: f.fullName(scope.Name),
+		tags:      f.mergeTags(scope.Tags),
+	}
+}
+
+func (f *Factory) key(name string, tags map[string]string) string {
+	return GetKey(f.fullName(name), f.mergeTags(tags))
+}
+
+func (f *Factory) fullName(name string) string {
+	if f.namespace == "" {
+		return name
+	}
+	if name == "" {
+		return f.namespace
+	}
+	return f.namespace + "." + name
+}
+
+func (f *Factory) mergeTags(tags map[string]string) map[string]string {
+	ret := make(map[string]string, len(f.tags)+len(tags))
+	for k, v := range f.tags {
+		ret[k] = v
+	}
+	for k, v := range tags {
+		ret[k] = v
+	}
+	return ret
+}
+
+type localCounter struct {
+	backend *Backend
+	key     string
+}
+
+func (c *localCounter) Inc(delta int64) {
+	c.backend.incCounter(c.key, delta)
+}
+
+type localGauge struct {
+	backend *Backend
+	key     string
+}
+
+func (g *localGauge) Update(value int64) {
+	g.backend.updateGauge(g.key, value)
+}
+
+type localTimer struct {
+	backend *Backend
+	key     string
+}
+
+func (t *localTimer) Record(d time.Duration) {
+	t.backend.recordTimer(t.key, d)
+}
+
+type localHistogram struct {
+	backend *Backend
+	key     string
+}
+
+func (h *localHistogram) Record(v float64) {
+	h.backend.recordHistogram(h.key, v)
+}
@@ -0,0 +1,111 @@
+package metricstest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"sample-app/pkg/metrics"
+)
+
+func TestFactory(t *testing.T) {
+	f := NewFactory()
+	tags := map[string]string{"error": "false"}
+
+	f.Counter(metrics.Options{Name: "requests", Tags: tags}).Inc(2)
+	f.Gauge(metrics.Options{Name: "in_flight"}).Update(3)
+	f.Timer(metrics.TimerOptions{Name: "latency"}).Record(time.Second)
+	f.Histogram(metrics.HistogramOptions{Name: "size"}).Record(1.5)
+
+	s := f.Snapshot()
+	if got := s.Counters["requests|error=false"]; got != 2 {
+		t.Errorf("counter = %d, want 2", got)
+	}
+	if got := s.Gauges["in_flight"]; got != 3 {
+		t.Errorf("gauge = %d, want 3", got)
+	}
+	if got := s.Timers["latency"]; len(got) != 1 || got[0] != time.Second {
+		t.Errorf("timer = %v, want [1s]", got)
+	}
+	if got := s.Histograms["size"]; len(got) != 1 || got[0] != 1.5 {
+		t.Errorf("histogram = %v, want [1.5]", got)
+	}
+}
+
+func TestFactoryRegistersMetricsOnCreation(t *testing.T) {
+	f := NewFactory()
+	f.Counter(metrics.Options{Name: "requests"})
+	f.Timer(metrics.TimerOptions{Name: "latency"})
+
+	s := f.Snapshot()
+	if v, ok := s.Counters["requests"]; !ok || v != 0 {
+		t.Errorf("counter = %d, %v; want a zero counter", v, ok)
+	}
+	if v, ok := s.Timers["latency"]; !ok || len(v) != 0 {
+		t.Errorf("timer = %v, %v; want a timer without observations", v, ok)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	f := NewFactory()
+	ns := f.Namespace(metrics.NSOptions{Name: "http", Tags: map[string]string{"a": "1", "b": "1"}}).
+		Namespace(metrics.NSOptions{Name: "server"})
+	ns.Counter(metrics.Options{Name: "requests", Tags: map[string]string{"b": "2"}}).Inc(1)
+
+	f.AssertCounterMetrics(t, ExpectedMetric{
+		Name:  "http.server.requests",
+		Tags:  map[string]string{"a": "1", "b": "2"},
+		Value: 1,
+	})
+}
+
+func TestSnapshotIsACopy(t *testing.T) {
+	f := NewFactory()
+	c := f.Counter(metrics.Options{Name: "requests"})
+	timer := f.Timer(metrics.TimerOptions{Name: "latency"})
+	timer.Record(time.Second)
+
+	s := f.Snapshot()
+	c.Inc(1)
+	timer.Record(time.Second)
+	s.Timers["latency"][0] = 0
+
+	if s.Counters["requests"] != 0 || len(s.Timers["latency"]) != 1 {
+		t.Errorf("snapshot changed with the metrics: %+v", s)
+	}
+	if got := f.Snapshot().Timers["latency"]; len(got) != 2 || got[0] != time.Second {
+		t.Errorf("metrics changed with the snapshot: %v", got)
+	}
+}
+
+func TestReset(t *testing.T) {
+	f := NewFactory()
+	requests := f.Counter(metrics.Options{Name: "requests"})
+	requests.Inc(1)
+	f.Gauge(metrics.Options{Name: "queue"}).Update(3)
+	f.Timer(metrics.TimerOptions{Name: "latency"}).Record(time.Second)
+	f.Reset()
+
+	s := f.Snapshot()
+	want := Snapshot{
+		Counters:   map[string]int64{"requests": 0},
+		Gauges:     map[string]int64{"queue": 0},
+		Timers:     map[string][]time.Duration{"latency": nil},
+		Histograms: map[string][]float64{},
+	}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("got %+v after Reset, want the metrics registered and zeroed", s)
+	}
+
+	requests.Inc(2)
+	if got := f.Snapshot().Counters["requests"]; got != 2 {
+		t.Errorf("got %d, want counting to restart from zero", got)
+	}
+}
+
+func TestGetKey(t *testing.T) {
+	got := GetKey("requests", map[string]string{"error": "false", "endpoint": "/books"})
+	if want := "requests|endpoint=/books|error=false"; got != want {
+		t.Errorf("GetKey = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+// Package otelmetrics implements metrics.Factory on top of an OpenTelemetry
+// MeterProvider, so that metrics are exported with the OpenTelemetry SDK.
+package otelmetrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"sample-app/pkg/metrics"
+)
+
+// scopeName names the instrumentation scope of the instruments.
+const scopeName = "sample-app/pkg/metrics/otelmetrics"
+
+// DefaultTimerBuckets are the bucket boundaries, in seconds, of timers
+// created without buckets. The SDK defaults suit milliseconds.
+var DefaultTimerBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Factory implements metrics.Factory with instruments of an OpenTelemetry
+// meter. Namespaces prefix the instrument names, separated by dots, and
+// tags become attributes.
+type Factory struct {
+	meter      metric.Meter
+	scope      string
+	tags       map[string]string
+	normalizer *strings.Replacer
+}
+
+// New creates a Factory creating instruments with meterProvider.
+func New(meterProvider metric.MeterProvider) *Factory {
+	return &Factory{
+		meter:      meterProvider.Meter(scopeName),
+		normalizer: strings.NewReplacer(" ", "_"),
+	}
+}
+
+// Counter implements Counter of metrics.Factory with an Int64Counter.
+func (f *Factory) Counter(options metrics.Options) metrics.Counter {
+	counter, err := f.meter.Int64Counter(f.subScope(options.Name),
+		metric.WithDescription(options.Help))
+	if err != nil {
+		otel.Handle(err)
+		return metrics.NullCounter
+	}
+	return &otelCounter{
+		counter: counter,
+		option:  f.attributes(options.Tags),
+	}
+}
+
+// Gauge implements Gauge of metrics.Factory with an Int64Gauge.
+func (f *Factory) Gauge(options metrics.Options) metrics.Gauge {
+	gauge, err := f.meter.Int64Gauge(f.subScope(options.Name),
+		metric.WithDescription(options.Help))
+	if err != nil {
+		otel.Handle(err)
+		return metrics.NullGauge
+	}
+	return &otelGauge{
+		gauge:  gauge,
+		option: f.attributes(options.Tags),
+	}
+}
+
+// Timer implements Timer of metrics.Factory with a Float64Histogram of
+// seconds.
+func (f *Factory) Timer(options metrics.TimerOptions) metrics.Timer {
+	bounds := DefaultTimerBuckets
+	if len(options.Buckets) > 0 {
+		bounds = make([]float64, len(options.Buckets))
+		for i, b := range options.Buckets {
+			bounds[i] = b.Seconds()
+		}
+	}
+	histogram, err := f.meter.Float64Histogram(f.subScope(options.Name),
+		metric.WithDescription(options.Help),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(bounds...))
+	if err != nil {
+		otel.Handle(err)
+		return metrics.NullTimer
+	}
+	return &otelTimer{
+		histogram: histogram,
+		option:    f.attributes(options.Tags),
+	}
+}
+
+// Histogram implements Histogram of metrics.Factory with a
+// Float64Histogram.
+func (f *Factory) Histogram(options metrics.HistogramOptions) metrics.Histogram {
+	opts := []metric.Float64HistogramOption{
+		metric.WithDescription(options.Help),
+	}
+	if len(options.Buckets) > 0 {
+		opts = append(opts, metric.WithExplicitBucketBoundaries(options.Buckets...))
+	}
+	histogram, err := f.meter.Float64Histogram(f.subScope(options.Name), opts...)
+	if err != nil {
+		otel.Handle(err)
+		return metrics.NullHistogram
+	}
+	return &otelHistogram{
+		histogram: histogram,
+		option:    f.attributes(options.Tags),
+	}
+}
+
+// Namespace implements Namespace of metrics.Factory.
+func (f *Factory) Namespace(scope metrics.NSOptions) metrics.Factory {
+	return &Factory{
+		meter:      f.meter,
+		scope:      f.subScope(scope.Name),
+		tags:       f.mergeTags(scope.Tags),
+		normalizer: f.normalizer,
+	}
+}
+
+type otelCounter struct {
+	counter metric.Int64Counter
+	option  metric.MeasurementOption
+}
+
+func (c *otelCounter) Inc(v int64) {
+	c.counter.Add(context.Background(), v, c.option)
+}
+
+type otelGauge struct {
+	gauge  metric.Int64Gauge
+	option metric.MeasurementOption
+}
+
+func (g *otelGauge) Update(v int64) {
+	g.gauge.Record(context.Background(), v, g.option)
+}
+
+type otelTimer struct {
+	histogram metric.Float64Histogram
+	option    metric.MeasurementOption
+}
+
+func (t *otelTimer) Record(d time.Duration) {
+	t.histogram.Record(context.Background(), d.Seconds(), t.option)
+}
+
+type otelHistogram struct {
+	histogram metric.Float64Histogram
+	option    metric.MeasurementOption
+}
+
+func (h *otelHistogram) Record(v float64) {
+	h.histogram.Record(context.Background(), v, h.option)
+}
+
+func (f *Factory) subScope(name string) string {
+	name = f.normalizer.Replace(name)
+	if f.scope == "" {
+		return name
+	}
+	if name == "" {
+		return f.scope
+	}
+	return f.scope + "." + name
+}
+
+func (f *Factory) mergeTags(tags map[string]string) map[string]string {
+	ret := make(map[string]string, len(f.tags)+len(tags))
+	for k, v := range f.tags {
+		ret[k] = v
+	}
+	for k, v := range tags {
+		ret[k] = v
+	}
+	return ret
+}
+
+// attributes returns the merged tags as an attribute set, computed once
+// per instrument rather than on every measurement.
+func (f *Factory) attributes(tags map[string]string) metric.MeasurementOption {
+	merged := f.mergeTags(tags)
+	attrs := make([]attribute.KeyValue, 0, len(merged))
+	for k, v := range merged {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return metric.WithAttributeSet(attribute.NewSet(attrs...))
+}
@@ -0,0 +1,114 @@
+package otelmetrics
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"sample-app/pkg/metrics"
+)
+
+// collect returns the metrics gathered by reader by name.
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Metrics {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	ret := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		if sm.Scope.Name != scopeName {
+			t.Errorf("got scope %q, want %q", sm.Scope.Name, scopeName)
+		}
+		for _, m := range sm.Metrics {
+			ret[m.Name] = m
+		}
+	}
+	return ret
+}
+
+func TestFactory(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	f := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))).
+		Namespace(metrics.NSOptions{Name: "book-service", Tags: map[string]string{"service": "books"}})
+
+	f.Counter(metrics.Options{Name: "requests", Tags: map[string]string{"error": "false"}, Help: "Number of requests"}).Inc(2)
+	f.Gauge(metrics.Options{Name: "queue depth"}).Update(7)
+	f.Timer(metrics.TimerOptions{Name: "latency", Buckets: []time.Duration{10 * time.Millisecond, 100 * time.Millisecond}}).Record(50 * time.Millisecond)
+	f.Timer(metrics.TimerOptions{Name: "default_latency"}).Record(time.Second)
+	f.Histogram(metrics.HistogramOptions{Name: "size", Buckets: []float64{1, 10}}).Record(5)
+
+	got := collect(t, reader)
+	service := attribute.NewSet(attribute.String("service", "books"))
+
+	requests := got["book-service.requests"]
+	if requests.Description != "Number of requests" {
+		t.Errorf("got description %q", requests.Description)
+	}
+	sum, ok := requests.Data.(metricdata.Sum[int64])
+	if !ok || !sum.IsMonotonic || len(sum.DataPoints) != 1 || sum.DataPoints[0].Value != 2 {
+		t.Fatalf("got requests %+v, want a monotonic sum of 2", requests.Data)
+	}
+	if want := attribute.NewSet(attribute.String("error", "false"), attribute.String("service", "books")); !sum.DataPoints[0].Attributes.Equals(&want) {
+		t.Errorf("got attributes %v, want the merged tags", sum.DataPoints[0].Attributes.ToSlice())
+	}
+
+	gauge, ok := got["book-service.queue_depth"].Data.(metricdata.Gauge[int64])
+	if !ok || len(gauge.DataPoints) != 1 || gauge.DataPoints[0].Value != 7 || !gauge.DataPoints[0].Attributes.Equals(&service) {
+		t.Errorf("got gauge %+v, want 7 tagged with the namespace tags", got["book-service.queue_depth"].Data)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		unit   string
+		bounds []float64
+	}{
+		{"book-service.latency", "s", []float64{0.01, 0.1}},
+		{"book-service.default_latency", "s", DefaultTimerBuckets},
+		{"book-service.size", "", []float64{1, 10}},
+	} {
+		m := got[tt.name]
+		if m.Unit != tt.unit {
+			t.Errorf("%s: got unit %q, want %q", tt.name, m.Unit, tt.unit)
+		}
+		h, ok := m.Data.(metricdata.Histogram[float64])
+		if !ok || len(h.DataPoints) != 1 {
+			t.Errorf("%s: got %+v, want one histogram data point", tt.name, m.Data)
+			continue
+		}
+		if !reflect.DeepEqual(h.DataPoints[0].Bounds, tt.bounds) {
+			t.Errorf("%s: got bounds %v, want %v", tt.name, h.DataPoints[0].Bounds, tt.bounds)
+		}
+	}
+	if h := got["book-service.latency"].Data.(metricdata.Histogram[float64]); h.DataPoints[0].Sum != 0.05 || h.DataPoints[0].BucketCounts[1] != 1 {
+		t.Errorf("got latency %+v, want 50ms recorded in seconds", h.DataPoints[0])
+	}
+}
+
+func TestFactoryReusesInstruments(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	f := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	f.Counter(metrics.Options{Name: "requests", Tags: map[string]string{"error": "false"}}).Inc(1)
+	f.Counter(metrics.Options{Name: "requests", Tags: map[string]string{"error": "false"}}).Inc(1)
+	f.Counter(metrics.Options{Name: "requests", Tags: map[string]string{"error": "true"}}).Inc(1)
+
+	got := collect(t, reader)
+	if len(got) != 1 {
+		t.Fatalf("got metrics %v, want requests only", got)
+	}
+	sum := got["requests"].Data.(metricdata.Sum[int64])
+	values := make(map[string]int64)
+	for _, dp := range sum.DataPoints {
+		v, _ := dp.Attributes.Value("error")
+		values[v.AsString()] = dp.Value
+	}
+	if want := map[string]int64{"false": 2, "true": 1}; !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+}
@@ -0,0 +1,73 @@
+package prometheus
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// vectorCache avoids registering the same metric vector twice, which
+// Prometheus rejects, when metrics only differ by their label values.
+type vectorCache struct {
+	registerer prometheus.Registerer
+	lock       sync.Mutex
+	cVecs      map[string]*prometheus.CounterVec
+	gVecs      map[string]*prometheus.GaugeVec
+	hVecs      map[string]*prometheus.HistogramVec
+}
+
+func newVectorCache(registerer prometheus.Registerer) *vectorCache {
+	return &vectorCache{
+		registerer: registerer,
+		cVecs:      make(map[string]*prometheus.CounterVec),
+		gVecs:      make(map[string]*prometheus.GaugeVec),
+		hVecs:      make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+func (c *vectorCache) getOrMakeCounterVec(opts prometheus.CounterOpts, labelNames []string) *prometheus.CounterVec {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := cacheKey(opts.Name, labelNames)
+	cv, ok := c.cVecs[key]
+	if !ok {
+		cv = prometheus.NewCounterVec(opts, labelNames)
+		c.registerer.MustRegister(cv)
+		c.cVecs[key] = cv
+	}
+	return cv
+}
+
+func (c *vectorCache) getOrMakeGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *prometheus.GaugeVec {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := cacheKey(opts.Name, labelNames)
+	gv, ok := c.gVecs[key]
+	if !ok {
+		gv = prometheus.NewGaugeVec(opts, labelNames)
+		c.registerer.MustRegister(gv)
+		c.gVecs[key] = gv
+	}
+	return gv
+}
+
+func (c *vectorCache) getOrMakeHistogramVec(opts prometheus.HistogramOpts, labelNames []string) *prometheus.HistogramVec {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := cacheKey(opts.Name, labelNames)
+	hv, ok := c.hVecs[key]
+	if !ok {
+		hv = prometheus.NewHistogramVec(opts, labelNames)
+		c.registerer.MustRegister(hv)
+		c.hVecs[key] = hv
+	}
+	return hv
+}
+
+func cacheKey(name string, labelNames []string) string {
+	return name + "||" + strings.Join(labelNames, ",")
+}
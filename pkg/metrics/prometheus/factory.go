@@ -0,0 +1,261 @@
+// Package prometheus implements metrics.Factory on top of a Prometheus
+// registry, which can be served in the Prometheus text format with
+// Handler.
+package prometheus
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"sample-app/pkg/metrics"
+)
+
+// Factory implements metrics.Factory backed by a Prometheus registry.
+type Factory struct {
+	scope      string
+	tags       map[string]string
+	cache      *vectorCache
+	buckets    []float64
+	normalizer *strings.Replacer
+	separator  string
+}
+
+// Option configures a Factory.
+type Option func(*Factory)
+
+// WithRegisterer sets the registerer metrics are registered with, the
+// default Prometheus registerer otherwise.
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return func(f *Factory) {
+		f.cache = newVectorCache(registerer)
+	}
+}
+
+// WithBuckets sets the default buckets of timers, in seconds, and
+// histograms created without buckets of their own.
+func WithBuckets(buckets []float64) Option {
+	return func(f *Factory) {
+		f.buckets = buckets
+	}
+}
+
+// New creates a Factory.
+func New(opts ...Option) *Factory {
+	f := &Factory{
+		cache:      newVectorCache(prometheus.DefaultRegisterer),
+		buckets:    prometheus.DefBuckets,
+		normalizer: strings.NewReplacer(".", "_", "-", "_"),
+		separator:  "_",
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Handler serves the metrics gathered by gatherer in the Prometheus text
+// format.
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
+
+// Counter implements Counter of metrics.Factory.
+func (f *Factory) Counter(options metrics.Options) metrics.Counter {
+	name := counterNamingConvention(f.subScope(options.Name))
+	tags := f.mergeTags(options.Tags)
+	labelNames := tagNames(tags)
+	opts := prometheus.CounterOpts{
+		Name: name,
+		Help: helpOrName(options.Help, name),
+	}
+	cv := f.cache.getOrMakeCounterVec(opts, labelNames)
+	return &counter{
+		counter: cv.WithLabelValues(tagValues(labelNames, tags)...),
+	}
+}
+
+// Gauge implements Gauge of metrics.Factory.
+func (f *Factory) Gauge(options metrics.Options) metrics.Gauge {
+	name := f.subScope(options.Name)
+	tags := f.mergeTags(options.Tags)
+	labelNames := tagNames(tags)
+	opts := prometheus.GaugeOpts{
+		Name: name,
+		Help: helpOrName(options.Help, name),
+	}
+	gv := f.cache.getOrMakeGaugeVec(opts, labelNames)
+	return &gauge{
+		gauge: gv.WithLabelValues(tagValues(labelNames, tags)...),
+	}
+}
+
+// Timer implements Timer of metrics.Factory. Durations are recorded in
+// seconds, as Prometheus recommends, so the name gets a _seconds suffix.
+func (f *Factory) Timer(options metrics.TimerOptions) metrics.Timer {
+	name := timerNamingConvention(f.subScope(options.Name))
+	tags := f.mergeTags(options.Tags)
+	labelNames := tagNames(tags)
+	opts := prometheus.HistogramOpts{
+		Name:    name,
+		Help:    helpOrName(options.Help, name),
+		Buckets: asFloatBuckets(options.Buckets, f.buckets),
+	}
+	hv := f.cache.getOrMakeHistogramVec(opts, labelNames)
+	return &timer{
+		histogram: hv.WithLabelValues(tagValues(labelNames, tags)...),
+	}
+}
+
+// Histogram implements Histogram of metrics.Factory.
+func (f *Factory) Histogram(options metrics.HistogramOptions) metrics.Histogram {
+	name := f.subScope(options.Name)
+	tags := f.mergeTags(options.Tags)
+	labelNames := tagNames(tags)
+	buckets := options.Buckets
+	if len(buckets) == 0 {
+		buckets = f.buckets
+	}
+	opts := prometheus.HistogramOpts{
+		Name:    name,
+		Help:    helpOrName(options.Help, name),
+		Buckets: buckets,
+	}
+	hv := f.cache.getOrMakeHistogramVec(opts, labelNames)
+	return &histogram{
+		histogram: hv.WithLabelValues(tagValues(labelNames, tags)...),
+	}
+}
+
+// Namespace implements Namespace of metrics.Factory. The names of the
+// metrics it creates are prefixed with the name of the scope.
+func (f *Factory) Namespace(scope metrics.NSOptions) metrics.Factory {
+	return &Factory{
+		scope:      f.subScope(scope.Name),
+		tags:       f.mergeTags(scope.Tags),
+		cache:      f.cache,
+		buckets:    f.buckets,
+		normalizer: f.normalizer,
+		separator:  f.separator,
+	}
+}
+
+type counter struct {
+	counter prometheus.Counter
+}
+
+func (c *counter) Inc(v int64) {
+	c.counter.Add(float64(v))
+}
+
+type gauge struct {
+	gauge prometheus.Gauge
+}
+
+func (g *gauge) Update(v int64) {
+	g.gauge.Set(float64(v))
+}
+
+type observer interface {
+	Observe(v float64)
+}
+
+type timer struct {
+	histogram observer
+}
+
+func (t *timer) Record(v time.Duration) {
+	t.histogram.Observe(v.Seconds())
+}
+
+type histogram struct {
+	histogram observer
+}
+
+func (h *histogram) Record(v float64) {
+	h.histogram.Observe(v)
+}
+
+// subScope joins the scope of f and name into a metric name, replacing the
+// characters Prometheus does not allow.
+func (f *Factory) subScope(name string) string {
+	if f.scope == "" {
+		return f.normalize(name)
+	}
+	if name == "" {
+		return f.normalize(f.scope)
+	}
+	return f.normalize(f.scope + f.separator + name)
+}
+
+func (f *Factory) normalize(v string) string {
+	return f.normalizer.Replace(v)
+}
+
+func (f *Factory) mergeTags(tags map[string]string) map[string]string {
+	ret := make(map[string]string, len(f.tags)+len(tags))
+	for k, v := range f.tags {
+		ret[k] = v
+	}
+	for k, v := range tags {
+		ret[k] = v
+	}
+	return ret
+}
+
+// tagNames returns the sorted keys of tags, so that the same tags always
+// map to the same label names.
+func tagNames(tags map[string]string) []string {
+	ret := make([]string, 0, len(tags))
+	for k := range tags {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func tagValues(labelNames []string, tags map[string]string) []string {
+	ret := make([]string, len(labelNames))
+	for i, name := range labelNames {
+		ret[i] = tags[name]
+	}
+	return ret
+}
+
+func counterNamingConvention(name string) string {
+	if !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return name
+}
+
+func timerNamingConvention(name string) string {
+	if !strings.HasSuffix(name, "_seconds") {
+		name += "_seconds"
+	}
+	return name
+}
+
+func helpOrName(help, name string) string {
+	if help = strings.TrimSpace(help); help != "" {
+		return help
+	}
+	return name
+}
+
+// asFloatBuckets converts duration buckets to seconds, returning def if
+// there are none.
+func asFloatBuckets(buckets []time.Duration, def []float64) []float64 {
+	if len(buckets) == 0 {
+		return def
+	}
+	ret := make([]float64, len(buckets))
+	for i, d := range buckets {
+		ret[i] = d.Seconds()
+	}
+	return ret
+}
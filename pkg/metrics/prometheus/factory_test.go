@@ -0,0 +1,80 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"sample-app/pkg/metrics"
+)
+
+func TestFactory(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	f := New(WithRegisterer(registry), WithBuckets([]float64{1, 10})).
+		Namespace(metrics.NSOptions{Name: "book-service", Tags: map[string]string{"service": "books"}})
+
+	f.Counter(metrics.Options{
+		Name: "requests",
+		Tags: map[string]string{"endpoint": "/books"},
+		Help: "Number of requests",
+	}).Inc(3)
+	f.Gauge(metrics.Options{Name: "queue.depth"}).Update(7)
+	timer := f.Timer(metrics.TimerOptions{Name: "latency", Buckets: []time.Duration{10 * time.Millisecond, 100 * time.Millisecond}})
+	timer.Record(0)
+	timer.Record(time.Second)
+	f.Histogram(metrics.HistogramOptions{Name: "size"}).Record(5)
+
+	expected := `
+# HELP book_service_latency_seconds book_service_latency_seconds
+# TYPE book_service_latency_seconds histogram
+book_service_latency_seconds_bucket{service="books",le="0.01"} 1
+book_service_latency_seconds_bucket{service="books",le="0.1"} 1
+book_service_latency_seconds_bucket{service="books",le="+Inf"} 2
+book_service_latency_seconds_sum{service="books"} 1
+book_service_latency_seconds_count{service="books"} 2
+# HELP book_service_queue_depth book_service_queue_depth
+# TYPE book_service_queue_depth gauge
+book_service_queue_depth{service="books"} 7
+# HELP book_service_requests_total Number of requests
+# TYPE book_service_requests_total counter
+book_service_requests_total{endpoint="/books",service="books"} 3
+# HELP book_service_size book_service_size
+# TYPE book_service_size histogram
+book_service_size_bucket{service="books",le="1"} 0
+book_service_size_bucket{service="books",le="10"} 1
+book_service_size_bucket{service="books",le="+Inf"} 1
+book_service_size_sum{service="books"} 5
+book_service_size_count{service="books"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFactoryReusesVectors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	f := New(WithRegisterer(registry))
+
+	// registering the same vector twice would panic
+	first := f.Counter(metrics.Options{Name: "requests_total", Tags: map[string]string{"error": "false"}})
+	again := f.Counter(metrics.Options{Name: "requests", Tags: map[string]string{"error": "false"}})
+	failed := f.Counter(metrics.Options{Name: "requests", Tags: map[string]string{"error": "true"}})
+	first.Inc(1)
+	again.Inc(1)
+	failed.Inc(1)
+	f.Timer(metrics.TimerOptions{Name: "latency_seconds"})
+	f.Timer(metrics.TimerOptions{Name: "latency"})
+
+	if got := testutil.ToFloat64(again.(*counter).counter); got != 2 {
+		t.Errorf("got %v, want both counters to share a value", got)
+	}
+	if n, err := testutil.GatherAndCount(registry, "requests_total"); err != nil || n != 2 {
+		t.Errorf("got %d requests_total series, %v; want one per label value", n, err)
+	}
+	if n, err := testutil.GatherAndCount(registry); err != nil || n != 3 {
+		t.Errorf("got %d series, %v; want the timers to share a histogram", n, err)
+	}
+}
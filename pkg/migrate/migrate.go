@@ -0,0 +1,401 @@
+// Package migrate applies versioned schema migrations to a database. Applied
+// migrations are recorded in the schema_migrations table, and a lock row in
+// schema_migrations_lock keeps concurrent instances from migrating at the
+// same time.
+package migrate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultLockTimeout is how long Up and Down wait for the migration lock.
+const DefaultLockTimeout = time.Minute
+
+// processNonce tells this process apart from earlier ones that had the same
+// pid, such as a container restarted with the service as pid 1.
+var processNonce = newProcessNonce()
+
+func newProcessNonce() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// the start time tells processes apart well enough
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+var (
+	// ErrIrreversible is returned by Down for migrations without a Down step.
+	ErrIrreversible = errors.New("migration cannot be reverted")
+	// ErrLocked is returned when the migration lock cannot be acquired in time.
+	ErrLocked = errors.New("migrations are locked")
+)
+
+// Migration is a single schema change. Up and Down run in a transaction
+// that also records the change, Down is nil if the migration cannot be
+// reverted.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Version int64
+	Name    string
+	// AppliedAt is nil for pending migrations.
+	AppliedAt *time.Time
+	// Unknown is set for applied migrations this binary does not know,
+	// e.g. because a newer release applied them.
+	Unknown bool
+}
+
+// SQL returns a migration step that executes script.
+func SQL(script string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Exec(script).Error
+	}
+}
+
+var sqlFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// ParseFS loads the SQL migrations in dir, from files named
+// <version>_<name>.up.sql and, for reversible ones, <version>_<name>.down.sql.
+func ParseFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := sqlFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		script, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = SQL(string(script))
+		} else {
+			m.Down = SQL(string(script))
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies a set of migrations to a database.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	host       string
+	owner      string
+
+	// LockTimeout bounds how long Up and Down wait for another instance
+	// to finish migrating, DefaultLockTimeout if zero.
+	LockTimeout time.Duration
+}
+
+// New creates a Migrator for the given migrations, which must have
+// distinct positive versions.
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		switch {
+		case m.Version <= 0:
+			return nil, fmt.Errorf("migration %q has version %d, versions must be positive", m.Name, m.Version)
+		case m.Up == nil:
+			return nil, fmt.Errorf("migration %d_%s has no up step", m.Version, m.Name)
+		case i > 0 && sorted[i-1].Version == m.Version:
+			return nil, fmt.Errorf("migrations %q and %q share version %d", sorted[i-1].Name, m.Name, m.Version)
+		}
+	}
+
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		migrations: sorted,
+		host:       host,
+		owner:      fmt.Sprintf("%s:%d:%s", host, os.Getpid(), processNonce),
+	}, nil
+}
+
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+type migrationLock struct {
+	ID         int `gorm:"primaryKey;autoIncrement:false"`
+	Owner      string
+	AcquiredAt time.Time
+}
+
+func (migrationLock) TableName() string { return "schema_migrations_lock" }
+
+func (m *Migrator) createTables(db *gorm.DB) error {
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			owner TEXT NOT NULL,
+			acquired_at DATETIME NOT NULL)`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Up applies every pending migration in version order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		done, err := appliedVersions(db)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := mig.Up(tx); err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("apply migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and
+// returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		var records []schemaMigration
+		if err := db.Order("version DESC").Limit(steps).Find(&records).Error; err != nil {
+			return err
+		}
+		for _, record := range records {
+			mig, ok := m.find(record.Version)
+			if !ok {
+				return fmt.Errorf("revert migration %d_%s: unknown to this binary", record.Version, record.Name)
+			}
+			if mig.Down == nil {
+				return fmt.Errorf("revert migration %d_%s: %w", mig.Version, mig.Name, ErrIrreversible)
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := mig.Down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, mig.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("revert migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists the known and applied migrations in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+	if err := m.createTables(db); err != nil {
+		return nil, err
+	}
+	done, err := appliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if record, ok := done[mig.Version]; ok {
+			s.AppliedAt = &record.AppliedAt
+			delete(done, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, record := range done {
+		statuses = append(statuses, Status{Version: record.Version, Name: record.Name, AppliedAt: &record.AppliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending returns the number of known migrations that have not been applied.
+// Unlike Status it only reads the database, so it suits frequent checks
+// such as readiness probes.
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	db := m.db.WithContext(ctx)
+	done, err := appliedVersions(db)
+	if err != nil {
+		// a database that was never migrated has no schema_migrations
+		if !db.Migrator().HasTable(&schemaMigration{}) {
+			return len(m.migrations), nil
+		}
+		return 0, err
+	}
+	pending := 0
+	for _, mig := range m.migrations {
+		if _, ok := done[mig.Version]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Unlock releases the migration lock whoever holds it. It is meant for
+// recovering from an instance that died while migrating.
+func (m *Migrator) Unlock(ctx context.Context) error {
+	db := m.db.WithContext(ctx)
+	if err := m.createTables(db); err != nil {
+		return err
+	}
+	return db.Where("id = 1").Delete(&migrationLock{}).Error
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	i := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version >= version })
+	if i < len(m.migrations) && m.migrations[i].Version == version {
+		return m.migrations[i], true
+	}
+	return Migration{}, false
+}
+
+func appliedVersions(db *gorm.DB) (map[int64]schemaMigration, error) {
+	var records []schemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[int64]schemaMigration, len(records))
+	for _, record := range records {
+		done[record.Version] = record
+	}
+	return done, nil
+}
+
+// abandoned reports whether lock is held by a process of this host that
+// no longer exists. Locks held on other hosts are never considered abandoned.
+func (m *Migrator) abandoned(lock migrationLock) bool {
+	// owners are host:pid:nonce
+	parts := strings.SplitN(lock.Owner, ":", 3)
+	if len(parts) < 2 || lock.Owner == m.owner || parts[0] != m.host {
+		return false
+	}
+	pid, err := strconv.Atoi(parts[1])
+	if err != nil || pid <= 0 {
+		return false
+	}
+	// with another nonce, our own pid belongs to an earlier process
+	return pid == os.Getpid() || !processExists(pid)
+}
+
+// withLock runs fn while holding the migration lock, waiting up to
+// LockTimeout for other instances to release it. Locks left by dead
+// processes of the same host are taken over.
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	db := m.db.WithContext(ctx)
+	if err := m.createTables(db); err != nil {
+		return err
+	}
+
+	timeout := m.LockTimeout
+	if timeout == 0 {
+		timeout = DefaultLockTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		result := db.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&migrationLock{ID: 1, Owner: m.owner, AcquiredAt: time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			break
+		}
+		var holder migrationLock
+		if err := db.Take(&holder, 1).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if m.abandoned(holder) {
+			// the holder died without releasing the lock
+			if err := db.Where("id = 1 AND owner = ?", holder.Owner).Delete(&migrationLock{}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w by %s since %s", ErrLocked, holder.Owner, holder.AcquiredAt.Format(time.RFC3339))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+	// release the lock even if ctx is done
+	defer m.db.Where("id = 1 AND owner = ?", m.owner).Delete(&migrationLock{})
+
+	return fn(db)
+}
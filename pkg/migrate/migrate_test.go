@@ -0,0 +1,292 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newMigrator(t *testing.T, db *gorm.DB, migrations ...Migration) *Migrator {
+	t.Helper()
+	m, err := New(db, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+var (
+	createA = Migration{Version: 1, Name: "a", Up: SQL("CREATE TABLE a (id INTEGER)"), Down: SQL("DROP TABLE a")}
+	createB = Migration{Version: 2, Name: "b", Up: SQL("CREATE TABLE b (id INTEGER)"), Down: SQL("DROP TABLE b")}
+)
+
+func versions(migrations []Migration) []int64 {
+	v := make([]int64, len(migrations))
+	for i, m := range migrations {
+		v[i] = m.Version
+	}
+	return v
+}
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER)")},
+		"m/0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id INTEGER)")},
+		"m/0001_first.down.sql":  {Data: []byte("DROP TABLE a")},
+		"m/README.md":            {Data: []byte("ignored")},
+		"m/0003_skipped.sql.bak": {Data: []byte("ignored")},
+	}
+	migrations, err := ParseFS(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(versions(migrations)); got != "[1 2]" {
+		t.Fatalf("got versions %s, want [1 2]", got)
+	}
+	if migrations[0].Name != "first" || migrations[0].Down == nil {
+		t.Errorf("got %+v, want the reversible migration first", migrations[0])
+	}
+	if migrations[1].Down != nil {
+		t.Errorf("migration 2 has no down script but got a Down step")
+	}
+}
+
+func TestParseFSErrors(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"down without up": {"m/0001_a.down.sql": {}},
+		"mismatched names": {
+			"m/0001_a.up.sql":   {},
+			"m/0001_b.down.sql": {},
+		},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseFS(fsys, "m"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := map[string][]Migration{
+		"zero version":      {{Version: 0, Name: "a", Up: createA.Up}},
+		"missing up":        {{Version: 1, Name: "a"}},
+		"duplicate version": {createA, {Version: 1, Name: "b", Up: createB.Up}},
+	}
+	for name, migrations := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(openDB(t), migrations); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := newMigrator(t, db, createB, createA)
+
+	if n, err := m.Pending(ctx); err != nil || n != 2 {
+		t.Fatalf("got %d pending migrations, %v; want 2", n, err)
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(versions(applied)); got != "[1 2]" {
+		t.Errorf("applied %s, want [1 2]", got)
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Errorf("second Up applied %v, %v; want nothing", versions(applied), err)
+	}
+	if !db.Migrator().HasTable("a") || !db.Migrator().HasTable("b") {
+		t.Error("tables were not created")
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(versions(reverted)); got != "[2]" {
+		t.Errorf("reverted %s, want [2]", got)
+	}
+	if db.Migrator().HasTable("b") {
+		t.Error("table b was not dropped")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Errorf("got statuses %+v, want 1 applied and 2 pending", statuses)
+	}
+}
+
+func TestPendingOnlyReads(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	m := newMigrator(t, db, createA, createB)
+
+	if n, err := m.Pending(ctx); err != nil || n != 2 {
+		t.Fatalf("got %d pending migrations, %v; want 2", n, err)
+	}
+	if db.Migrator().HasTable("schema_migrations") || db.Migrator().HasTable("schema_migrations_lock") {
+		t.Error("Pending created the migration tables")
+	}
+
+	if _, err := newMigrator(t, db, createA).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.Pending(ctx); err != nil || n != 1 {
+		t.Errorf("got %d pending migrations, %v; want 1", n, err)
+	}
+}
+
+func TestStatusReportsUnknownMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	if _, err := newMigrator(t, db, createA, createB).Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := newMigrator(t, db, createA).Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[1].Unknown {
+		t.Errorf("got statuses %+v, want migration 2 unknown", statuses)
+	}
+}
+
+func TestUpRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := openDB(t)
+	failing := Migration{Version: 2, Name: "failing", Up: func(tx *gorm.DB) error {
+		if err := tx.Exec("CREATE TABLE partial (id INTEGER)").Error; err != nil {
+			return err
+		}
+		return errors.New("boom")
+	}}
+	applied, err := newMigrator(t, db, createA, failing).Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "2_failing") {
+		t.Fatalf("got %v, want the failure of 2_failing", err)
+	}
+	if got := fmt.Sprint(versions(applied)); got != "[1]" {
+		t.Errorf("applied %s, want [1]", got)
+	}
+	if db.Migrator().HasTable("partial") {
+		t.Error("the failed migration was not rolled back")
+	}
+}
+
+func TestDownIrreversible(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t, openDB(t), Migration{Version: 1, Name: "a", Up: createA.Up})
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Down(ctx, 1); !errors.Is(err, ErrIrreversible) {
+		t.Errorf("got %v, want ErrIrreversible", err)
+	}
+}
+
+// holdLock makes owner hold the migration lock of db.
+func holdLock(t *testing.T, m *Migrator, owner string) {
+	t.Helper()
+	if err := m.createTables(m.db); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.db.Create(&migrationLock{ID: 1, Owner: owner, AcquiredAt: time.Now()}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockHeldByAnotherHost(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t, openDB(t), createA)
+	m.LockTimeout = 100 * time.Millisecond
+	holdLock(t, m, "elsewhere:1")
+
+	if _, err := m.Up(ctx); !errors.Is(err, ErrLocked) || !strings.Contains(err.Error(), "elsewhere:1") {
+		t.Fatalf("got %v, want ErrLocked naming the holder", err)
+	}
+	if err := m.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Errorf("Up after Unlock: %v", err)
+	}
+}
+
+func TestLockHeldByLiveLocalProcess(t *testing.T) {
+	m := newMigrator(t, openDB(t), createA)
+	m.LockTimeout = 100 * time.Millisecond
+	holdLock(t, m, fmt.Sprintf("%s:%d", m.host, os.Getppid()))
+
+	if _, err := m.Up(context.Background()); !errors.Is(err, ErrLocked) {
+		t.Fatalf("got %v, want ErrLocked", err)
+	}
+}
+
+func TestLockHeldByThisProcess(t *testing.T) {
+	m := newMigrator(t, openDB(t), createA)
+	m.LockTimeout = 100 * time.Millisecond
+	holdLock(t, m, newMigrator(t, m.db).owner)
+
+	if _, err := m.Up(context.Background()); !errors.Is(err, ErrLocked) {
+		t.Fatalf("got %v, want ErrLocked", err)
+	}
+}
+
+func TestLockAbandonedByEarlierProcessWithSamePid(t *testing.T) {
+	m := newMigrator(t, openDB(t), createA)
+	m.LockTimeout = 100 * time.Millisecond
+	holdLock(t, m, fmt.Sprintf("%s:%d:%s", m.host, os.Getpid(), "0123456789abcdef"))
+
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Errorf("got %v, want the lock of the restarted process taken over", err)
+	}
+}
+
+func TestLockAbandonedByDeadLocalProcess(t *testing.T) {
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skip("cannot start a process:", err)
+	}
+	if processExists(cmd.Process.Pid) {
+		t.Skip("cannot tell whether processes exist on this platform")
+	}
+
+	m := newMigrator(t, openDB(t), createA)
+	m.LockTimeout = 100 * time.Millisecond
+	holdLock(t, m, fmt.Sprintf("%s:%d", m.host, cmd.Process.Pid))
+
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("got %v, want the abandoned lock taken over", err)
+	}
+	var count int64
+	if err := m.db.Model(&migrationLock{}).Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("got %d locks, %v; want the lock released", count, err)
+	}
+}
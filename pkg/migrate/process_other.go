@@ -0,0 +1,9 @@
+//go:build !unix
+
+package migrate
+
+// processExists reports whether a process with the given pid runs on this
+// host. It cannot tell on this platform, so it assumes the process exists.
+func processExists(pid int) bool {
+	return true
+}
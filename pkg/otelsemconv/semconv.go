@@ -4,6 +4,7 @@
 package otelsemconv
 
 import (
+	semconv120 "go.opentelemetry.io/otel/semconv/v1.20.0"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
@@ -24,6 +25,11 @@ const (
 	DBSystemKey               = semconv.DBSystemKey
 	PeerServiceKey            = semconv.PeerServiceKey
 	HTTPResponseStatusCodeKey = semconv.HTTPResponseStatusCodeKey
+	HTTPRouteKey              = semconv.HTTPRouteKey
+
+	// HTTPStatusCodeKey is the status code attribute of the conventions
+	// before v1.21, which otelmux still sets.
+	HTTPStatusCodeKey = semconv120.HTTPStatusCodeKey
 )
 
 var HTTPResponseStatusCode = semconv.HTTPResponseStatusCode
\ No newline at end of file
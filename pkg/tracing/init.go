@@ -27,7 +27,39 @@ import (
 
 var once sync.Once
 
-func InitOTEL(serviceName string, exporterType string, metricsFactory metrics.Factory, logger log.Factory) trace.TracerProvider {
+// ExporterOptions selects where spans are exported.
+type ExporterOptions struct {
+	// Type is the exporter to use: otlp, stdout or none.
+	Type string
+	// Endpoint is the base URL of the OTLP/HTTP collector. If empty, the
+	// OTEL_EXPORTER_OTLP_* environment variables apply.
+	Endpoint string
+	// Headers are sent with every OTLP export.
+	Headers map[string]string
+}
+
+// RPCMetricsOptions selects the spans the rpcmetrics Observer reports.
+type RPCMetricsOptions struct {
+	// SpanKinds are the names of the span kinds to report, such as server
+	// and client. If empty, the Observer defaults apply.
+	SpanKinds []string
+	// MaxEndpoints is how many endpoints are told apart. If zero, the
+	// Observer default applies.
+	MaxEndpoints int
+	// MaxPeers is how many dependencies client spans are told apart by. If
+	// zero, the Observer default applies.
+	MaxPeers int
+	// Normalizer names how endpoint names are normalized: route replaces
+	// their identifiers with placeholders, simple only their unsafe
+	// characters. If empty, route is used.
+	Normalizer string
+}
+
+// InitOTEL creates a TracerProvider for the given service that exports spans
+// as configured by exporter and feeds the rpcmetrics Observer configured by
+// rpcMetrics. It also registers the global text map propagator.
+// Callers own the returned provider and must Shutdown it to flush spans.
+func InitOTEL(serviceName string, exporter ExporterOptions, rpcMetrics RPCMetricsOptions, metricsFactory metrics.Factory, logger log.Factory) *sdktrace.TracerProvider {
 	once.Do(func() {
 		otel.SetTextMapPropagator(
 			propagation.NewCompositeTextMapPropagator(
@@ -36,13 +68,21 @@ func InitOTEL(serviceName string, exporterType string, metricsFactory metrics.Fa
 			))
 	})
 
-	exp, err := createOtelExporter(exporterType)
+	exp, err := createOtelExporter(exporter)
 	if err != nil {
-		logger.Bg().Fatal("cannot create exporter", zap.String("exporterType", exporterType), zap.Error(err))
+		logger.Bg().Fatal("cannot create exporter", zap.String("exporterType", exporter.Type), zap.Error(err))
 	}
-	logger.Bg().Debug("using " + exporterType + " trace exporter")
+	logger.Bg().Debug("using " + exporter.Type + " trace exporter")
 
-	rpcmetricsObserver := rpcmetrics.NewObserver(metricsFactory, rpcmetrics.DefaultNameNormalizer)
+	observerOpts, err := rpcMetricsObserverOptions(rpcMetrics)
+	if err != nil {
+		logger.Bg().Fatal("invalid rpcmetrics options", zap.Error(err))
+	}
+	rpcmetricsObserver := rpcmetrics.NewObserver(
+		metricsFactory,
+		rpcmetrics.DefaultRouteNameNormalizer,
+		observerOpts...,
+	)
 
 	res, err := resource.New(
 		context.Background(),
@@ -56,30 +96,83 @@ func InitOTEL(serviceName string, exporterType string, metricsFactory metrics.Fa
 		logger.Bg().Fatal("resource creation failed", zap.Error(err))
 	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp, sdktrace.WithBatchTimeout(1000*time.Millisecond)),
+	opts := []sdktrace.TracerProviderOption{
 		sdktrace.WithSpanProcessor(rpcmetricsObserver),
 		sdktrace.WithResource(res),
-	)
+	}
+	if exp != nil {
+		opts = append(opts, sdktrace.WithBatcher(exp, sdktrace.WithBatchTimeout(1000*time.Millisecond)))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
 	logger.Bg().Debug("Created OTEL tracer", zap.String("service-name", serviceName))
 	return tp
 }
 
+func rpcMetricsObserverOptions(options RPCMetricsOptions) ([]rpcmetrics.Option, error) {
+	var opts []rpcmetrics.Option
+	switch options.Normalizer {
+	case "", "route":
+	case "simple":
+		opts = append(opts, rpcmetrics.WithNameNormalizer(rpcmetrics.DefaultNameNormalizer))
+	default:
+		return nil, fmt.Errorf("unrecognized endpoint normalizer %s", options.Normalizer)
+	}
+	if options.MaxEndpoints > 0 {
+		opts = append(opts, rpcmetrics.WithMaxEndpoints(options.MaxEndpoints))
+	}
+	if options.MaxPeers > 0 {
+		opts = append(opts, rpcmetrics.WithMaxPeers(options.MaxPeers))
+	}
+	if len(options.SpanKinds) > 0 {
+		kinds := make([]trace.SpanKind, 0, len(options.SpanKinds))
+		for _, name := range options.SpanKinds {
+			kind, err := parseSpanKind(name)
+			if err != nil {
+				return nil, err
+			}
+			kinds = append(kinds, kind)
+		}
+		opts = append(opts, rpcmetrics.WithSpanKinds(kinds...))
+	}
+	return opts, nil
+}
+
+func parseSpanKind(name string) (trace.SpanKind, error) {
+	for _, kind := range []trace.SpanKind{
+		trace.SpanKindInternal,
+		trace.SpanKindServer,
+		trace.SpanKindClient,
+		trace.SpanKindProducer,
+		trace.SpanKindConsumer,
+	} {
+		if kind.String() == name {
+			return kind, nil
+		}
+	}
+	return trace.SpanKindUnspecified, fmt.Errorf("unrecognized span kind %s", name)
+}
+
 func withSecure() bool {
 	return strings.HasPrefix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "https://") ||
 		strings.ToLower(os.Getenv("OTEL_EXPORTER_OTLP_INSECURE")) == "false"
 }
 
-func createOtelExporter(exporterType string) (sdktrace.SpanExporter, error) {
+func createOtelExporter(options ExporterOptions) (sdktrace.SpanExporter, error) {
 	var exporter sdktrace.SpanExporter
 	var err error
-	switch exporterType {
+	switch options.Type {
 	case "jaeger":
 		return nil, errors.New("jaeger exporter is no longer supported, please use otlp")
 	case "otlp":
 		var opts []otlptracehttp.Option
-		if !withSecure() {
-			opts = []otlptracehttp.Option{otlptracehttp.WithInsecure()}
+		if options.Endpoint != "" {
+			// the scheme of the URL decides whether TLS is used
+			opts = append(opts, otlptracehttp.WithEndpointURL(strings.TrimSuffix(options.Endpoint, "/")+"/v1/traces"))
+		} else if !withSecure() {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(options.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(options.Headers))
 		}
 		exporter, err = otlptrace.New(
 			context.Background(),
@@ -87,8 +180,12 @@ func createOtelExporter(exporterType string) (sdktrace.SpanExporter, error) {
 		)
 	case "stdout":
 		exporter, err = stdouttrace.New()
+	case "none":
+		// spans are still recorded so that rpcmetrics are emitted,
+		// they are just not exported anywhere.
+		return nil, nil
 	default:
-		return nil, fmt.Errorf("unrecognized exporter type %s", exporterType)
+		return nil, fmt.Errorf("unrecognized exporter type %s", options.Type)
 	}
 	return exporter, err
 }
\ No newline at end of file
@@ -0,0 +1,36 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"sample-app/pkg/log"
+	"sample-app/pkg/metrics"
+)
+
+func TestCreateOtelExporter(t *testing.T) {
+	if exp, err := createOtelExporter(ExporterOptions{Type: "none"}); err != nil || exp != nil {
+		t.Errorf("none: got %v, %v; want no exporter", exp, err)
+	}
+	if exp, err := createOtelExporter(ExporterOptions{Type: "stdout"}); err != nil || exp == nil {
+		t.Errorf("stdout: got %v, %v; want an exporter", exp, err)
+	}
+	for _, exporterType := range []string{"jaeger", "zipkin", ""} {
+		if _, err := createOtelExporter(ExporterOptions{Type: exporterType}); err == nil {
+			t.Errorf("%q: expected an error", exporterType)
+		}
+	}
+}
+
+func TestInitOTELWithoutExporterRecordsSpans(t *testing.T) {
+	tp := InitOTEL("test", ExporterOptions{Type: "none"}, RPCMetricsOptions{}, metrics.NullFactory, log.NewFactory(zap.NewNop()))
+	defer tp.Shutdown(context.Background())
+
+	_, span := tp.Tracer("test").Start(context.Background(), "op")
+	defer span.End()
+	if !span.IsRecording() || !span.SpanContext().IsValid() {
+		t.Error("spans are not recorded without an exporter")
+	}
+}
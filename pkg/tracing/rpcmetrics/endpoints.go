@@ -9,6 +9,7 @@ import "sync"
 // normalizedEndpoints is a cache for endpointName -> safeName mappings.
 type normalizedEndpoints struct {
 	names      map[string]string
+	safeNames  map[string]struct{}
 	maxSize    int
 	normalizer NameNormalizer
 	mux        sync.RWMutex
@@ -19,34 +20,51 @@ func newNormalizedEndpoints(maxSize int, normalizer NameNormalizer) *normalizedE
 		maxSize:    maxSize,
 		normalizer: normalizer,
 		names:      make(map[string]string, maxSize),
+		safeNames:  make(map[string]struct{}, maxSize),
 	}
 }
 
 // normalize looks up the name in the cache, if not found it uses normalizer
-// to convert the name to a safe name. If called with more than maxSize unique
-// names it returns "" for all other names beyond those already cached.
+// to convert the name to a safe name. Once maxSize unique safe names are
+// known it returns "" for all names converted to other safe names. Since
+// many names can share a safe name, only the first maxSize names are cached.
 func (n *normalizedEndpoints) normalize(name string) string {
 	n.mux.RLock()
 	norm, ok := n.names[name]
-	l := len(n.names)
 	n.mux.RUnlock()
 	if ok {
 		return norm
 	}
-	if l >= n.maxSize {
-		return ""
-	}
 	return n.normalizeWithLock(name)
 }
 
 func (n *normalizedEndpoints) normalizeWithLock(name string) string {
 	norm := n.normalizer.Normalize(name)
+
+	// names that would not change the cache are resolved under the read
+	// lock, so that a full cache does not serialize uncached names
+	n.mux.RLock()
+	_, known := n.safeNames[norm]
+	overflow := !known && len(n.safeNames) >= n.maxSize
+	cacheFull := len(n.names) >= n.maxSize
+	n.mux.RUnlock()
+	if overflow {
+		return ""
+	}
+	if known && cacheFull {
+		return norm
+	}
+
 	n.mux.Lock()
 	defer n.mux.Unlock()
-	// cache may have grown while we were not holding the lock
-	if len(n.names) >= n.maxSize {
-		return ""
+	if _, ok := n.safeNames[norm]; !ok {
+		if len(n.safeNames) >= n.maxSize {
+			return ""
+		}
+		n.safeNames[norm] = struct{}{}
+	}
+	if len(n.names) < n.maxSize {
+		n.names[name] = norm
 	}
-	n.names[name] = norm
 	return norm
 }
\ No newline at end of file
@@ -13,6 +13,9 @@ import (
 const (
 	otherEndpointsPlaceholder = "other"
 	endpointNameMetricTag     = "endpoint"
+	peerServiceMetricTag      = "peer_service"
+	dbSystemMetricTag         = "db_system"
+	clientMetricsNamespace    = "client"
 )
 
 // Metrics is a collection of metrics for an endpoint describing
@@ -25,10 +28,10 @@ type Metrics struct {
 	RequestCountFailures metrics.Counter `metric:"requests" tags:"error=true"`
 
 	// RequestLatencySuccess is a latency histogram of successful requests.
-	RequestLatencySuccess metrics.Timer `metric:"request_latency" tags:"error=false"`
+	RequestLatencySuccess metrics.Timer `metric:"request_latency" tags:"error=false" buckets:"1ms,5ms,10ms,25ms,50ms,100ms,200ms,300ms,500ms,750ms,1s,2s,5s"`
 
 	// RequestLatencyFailures is a latency histogram of failed requests.
-	RequestLatencyFailures metrics.Timer `metric:"request_latency" tags:"error=true"`
+	RequestLatencyFailures metrics.Timer `metric:"request_latency" tags:"error=true" buckets:"1ms,5ms,10ms,25ms,50ms,100ms,200ms,300ms,500ms,750ms,1s,2s,5s"`
 
 	// HTTPStatusCode2xx is a counter of the total number of requests with HTTP status code 200-299
 	HTTPStatusCode2xx metrics.Counter `metric:"http_requests" tags:"status_code=2xx"`
@@ -63,6 +66,7 @@ type MetricsByEndpoint struct {
 	metricsFactory    metrics.Factory
 	endpoints         *normalizedEndpoints
 	metricsByEndpoint map[string]*Metrics
+	overflows         metrics.Counter
 	mux               sync.RWMutex
 }
 
@@ -75,6 +79,10 @@ func newMetricsByEndpoint(
 		metricsFactory:    metricsFactory,
 		endpoints:         newNormalizedEndpoints(maxNumberOfEndpoints, normalizer),
 		metricsByEndpoint: make(map[string]*Metrics, maxNumberOfEndpoints+1), // +1 for "other"
+		overflows: metricsFactory.Counter(metrics.Options{
+			Name: "endpoint_overflow_spans",
+			Help: "Number of spans recorded under the endpoint \"other\" because there were too many endpoints",
+		}),
 	}
 }
 
@@ -82,6 +90,7 @@ func (m *MetricsByEndpoint) get(endpoint string) *Metrics {
 	safeName := m.endpoints.normalize(endpoint)
 	if safeName == "" {
 		safeName = otherEndpointsPlaceholder
+		m.overflows.Inc(1)
 	}
 	m.mux.RLock()
 	met := m.metricsByEndpoint[safeName]
@@ -113,3 +122,75 @@ func (m *MetricsByEndpoint) getWithWriteLock(safeName string) *Metrics {
 	m.metricsByEndpoint[safeName] = met
 	return met
 }
+
+// peer identifies the dependency called by a client span.
+type peer struct {
+	service  string
+	dbSystem string
+}
+
+// metricsByPeer is a registry of the metrics of the calls to each dependency,
+// by endpoint. Only maxNumberOfPeers dependencies are told apart, calls to
+// all others are recorded with the peer service and db system "other".
+type metricsByPeer struct {
+	metricsFactory       metrics.Factory
+	normalizer           NameNormalizer
+	maxNumberOfEndpoints int
+	maxNumberOfPeers     int
+	metricsByPeer        map[peer]*MetricsByEndpoint
+	overflows            metrics.Counter
+	mux                  sync.RWMutex
+}
+
+func newMetricsByPeer(
+	metricsFactory metrics.Factory,
+	normalizer NameNormalizer,
+	maxNumberOfEndpoints int,
+	maxNumberOfPeers int,
+) *metricsByPeer {
+	return &metricsByPeer{
+		metricsFactory:       metricsFactory,
+		normalizer:           normalizer,
+		maxNumberOfEndpoints: maxNumberOfEndpoints,
+		maxNumberOfPeers:     maxNumberOfPeers,
+		metricsByPeer:        make(map[peer]*MetricsByEndpoint, maxNumberOfPeers+1), // +1 for "other"
+		overflows: metricsFactory.Counter(metrics.Options{
+			Name: "peer_overflow_spans",
+			Help: "Number of spans recorded under the peer \"other\" because there were too many peers",
+		}),
+	}
+}
+
+func (m *metricsByPeer) get(p peer, endpoint string) *Metrics {
+	m.mux.RLock()
+	byEndpoint := m.metricsByPeer[p]
+	m.mux.RUnlock()
+	if byEndpoint == nil {
+		byEndpoint = m.getWithWriteLock(p)
+	}
+	return byEndpoint.get(endpoint)
+}
+
+func (m *metricsByPeer) getWithWriteLock(p peer) *MetricsByEndpoint {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if byEndpoint, ok := m.metricsByPeer[p]; ok {
+		return byEndpoint
+	}
+	if len(m.metricsByPeer) >= m.maxNumberOfPeers {
+		m.overflows.Inc(1)
+		p = peer{service: otherEndpointsPlaceholder, dbSystem: otherEndpointsPlaceholder}
+		if byEndpoint, ok := m.metricsByPeer[p]; ok {
+			return byEndpoint
+		}
+	}
+
+	factory := m.metricsFactory.Namespace(metrics.NSOptions{Tags: map[string]string{
+		peerServiceMetricTag: m.normalizer.Normalize(p.service),
+		dbSystemMetricTag:    m.normalizer.Normalize(p.dbSystem),
+	}})
+	byEndpoint := newMetricsByEndpoint(factory, m.normalizer, m.maxNumberOfEndpoints)
+	m.metricsByPeer[p] = byEndpoint
+	return byEndpoint
+}
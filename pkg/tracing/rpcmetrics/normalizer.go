@@ -4,6 +4,8 @@
 
 package rpcmetrics
 
+import "strings"
+
 // NameNormalizer is used to convert the endpoint names to strings
 // that can be safely used as tags in the metrics.
 type NameNormalizer interface {
@@ -87,4 +89,70 @@ func (n *SimpleNameNormalizer) safeByte(b byte) bool {
 		}
 	}
 	return false
+}
+
+// DefaultRouteNameNormalizer replaces the identifiers in endpoint names with
+// placeholders before applying DefaultNameNormalizer, which turns the braces
+// of the placeholders into '_': "GET /books/42" becomes "GET_/books/_id_".
+var DefaultRouteNameNormalizer = &RouteNameNormalizer{Next: DefaultNameNormalizer}
+
+// RouteNameNormalizer collapses the path segments of endpoint names that look
+// like identifiers into placeholders, so that requests to the same route share
+// their metrics: numbers become "{id}", UUIDs "{uuid}" and hexadecimal strings
+// of 16 characters or more, such as hashes, "{hash}". Without Next,
+// "GET /books/42" becomes "GET /books/{id}"; Next may rewrite the
+// placeholders, as DefaultNameNormalizer does with their braces.
+type RouteNameNormalizer struct {
+	// Next, if not nil, normalizes the names once their identifiers are replaced.
+	Next NameNormalizer
+}
+
+// Normalize replaces the identifiers in name and then applies Next.
+func (n *RouteNameNormalizer) Normalize(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		switch {
+		case isNumeric(segment):
+			segments[i] = "{id}"
+		case isUUID(segment):
+			segments[i] = "{uuid}"
+		case len(segment) >= 16 && isHex(segment):
+			segments[i] = "{hash}"
+		}
+	}
+	name = strings.Join(segments, "/")
+	if n.Next != nil {
+		return n.Next.Normalize(name)
+	}
+	return name
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID checks if s is a UUID in its canonical 8-4-4-4-12 form.
+func isUUID(s string) bool {
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return false
+	}
+	return isHex(s[0:8]) && isHex(s[9:13]) && isHex(s[14:18]) && isHex(s[19:23]) && isHex(s[24:])
 }
\ No newline at end of file
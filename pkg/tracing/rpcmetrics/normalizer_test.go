@@ -0,0 +1,52 @@
+package rpcmetrics
+
+import "testing"
+
+func TestRouteNameNormalizer(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"GET /books/42", "GET /books/{id}"},
+		{"GET /books/42/authors/7", "GET /books/{id}/authors/{id}"},
+		{"GET /users/123e4567-e89b-12d3-a456-426614174000", "GET /users/{uuid}"},
+		{"GET /blobs/0123456789abcdef0123", "GET /blobs/{hash}"},
+		{"GET /blobs/cafe", "GET /blobs/cafe"},
+		{"GET /books/", "GET /books/"},
+		{"GET /books/v2", "GET /books/v2"},
+	}
+	n := &RouteNameNormalizer{}
+	for _, tt := range tests {
+		if got := n.Normalize(tt.name); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultRouteNameNormalizer(t *testing.T) {
+	if got := DefaultRouteNameNormalizer.Normalize("GET /books/42"); got != "GET_/books/_id_" {
+		t.Errorf("got %q, want GET_/books/_id_", got)
+	}
+}
+
+func TestNormalizedEndpointsCountsSafeNames(t *testing.T) {
+	n := newNormalizedEndpoints(2, DefaultRouteNameNormalizer)
+
+	// many raw names sharing a safe name only take one place
+	for _, name := range []string{"GET /books/1", "GET /books/2", "GET /books/3"} {
+		if got := n.normalize(name); got != "GET_/books/_id_" {
+			t.Errorf("normalize(%q) = %q, want GET_/books/_id_", name, got)
+		}
+	}
+	if got := n.normalize("GET /authors"); got != "GET_/authors" {
+		t.Errorf("got %q, want GET_/authors", got)
+	}
+	if got := n.normalize("GET /trash"); got != "" {
+		t.Errorf("got %q past the limit, want \"\"", got)
+	}
+	if got := n.normalize("GET /books/4"); got != "GET_/books/_id_" {
+		t.Errorf("got %q past the limit, want the known GET_/books/_id_", got)
+	}
+	if len(n.names) > 2 {
+		t.Errorf("cached %d names, want at most 2", len(n.names))
+	}
+}
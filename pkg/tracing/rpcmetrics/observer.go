@@ -17,23 +17,98 @@ import (
 	"sample-app/pkg/otelsemconv"
 )
 
-const defaultMaxNumberOfEndpoints = 200
+const (
+	defaultMaxNumberOfEndpoints = 200
+	defaultMaxNumberOfPeers     = 50
+)
 
 var _ sdktrace.SpanProcessor = (*Observer)(nil)
 
 // Observer is an observer that can emit RPC metrics.
 type Observer struct {
 	metricsByEndpoint *MetricsByEndpoint
+	metricsByPeer     *metricsByPeer
+	spanKinds         map[trace.SpanKind]bool
+}
+
+type options struct {
+	normalizer           NameNormalizer
+	maxNumberOfEndpoints int
+	maxNumberOfPeers     int
+	spanKinds            map[trace.SpanKind]bool
+}
+
+// Option configures an Observer.
+type Option func(*options)
+
+// WithNameNormalizer replaces the NameNormalizer passed to NewObserver, for
+// example with DefaultRouteNameNormalizer when endpoint names hold IDs.
+func WithNameNormalizer(normalizer NameNormalizer) Option {
+	return func(o *options) {
+		o.normalizer = normalizer
+	}
+}
+
+// WithMaxEndpoints sets how many endpoints the Observer tells apart, 200 by
+// default. Spans of further endpoints are recorded under the endpoint
+// "other" and counted by the endpoint_overflow_spans counter.
+func WithMaxEndpoints(maxNumberOfEndpoints int) Option {
+	return func(o *options) {
+		if maxNumberOfEndpoints > 0 {
+			o.maxNumberOfEndpoints = maxNumberOfEndpoints
+		}
+	}
+}
+
+// WithMaxPeers sets how many dependencies the Observer tells apart in the
+// metrics of client spans, 50 by default. Calls to further dependencies are
+// recorded under the peer service and db system "other" and their spans
+// counted by the client.peer_overflow_spans counter.
+func WithMaxPeers(maxNumberOfPeers int) Option {
+	return func(o *options) {
+		if maxNumberOfPeers > 0 {
+			o.maxNumberOfPeers = maxNumberOfPeers
+		}
+	}
+}
+
+// WithSpanKinds selects the kinds of spans the Observer emits metrics for,
+// only server spans by default. Client and producer spans, the calls of the
+// service to its dependencies, are recorded under the "client" namespace and
+// tagged with the peer service and database system they call.
+func WithSpanKinds(kinds ...trace.SpanKind) Option {
+	return func(o *options) {
+		o.spanKinds = make(map[trace.SpanKind]bool, len(kinds))
+		for _, kind := range kinds {
+			o.spanKinds[kind] = true
+		}
+	}
 }
 
 // NewObserver creates a new observer that can emit RPC metrics.
-func NewObserver(metricsFactory metrics.Factory, normalizer NameNormalizer) *Observer {
+func NewObserver(metricsFactory metrics.Factory, normalizer NameNormalizer, opts ...Option) *Observer {
+	o := options{
+		normalizer:           normalizer,
+		maxNumberOfEndpoints: defaultMaxNumberOfEndpoints,
+		maxNumberOfPeers:     defaultMaxNumberOfPeers,
+		spanKinds:            map[trace.SpanKind]bool{trace.SpanKindServer: true},
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
 	return &Observer{
 		metricsByEndpoint: newMetricsByEndpoint(
 			metricsFactory,
-			normalizer,
-			defaultMaxNumberOfEndpoints,
+			o.normalizer,
+			o.maxNumberOfEndpoints,
+		),
+		metricsByPeer: newMetricsByPeer(
+			metricsFactory.Namespace(metrics.NSOptions{Name: clientMetricsNamespace}),
+			o.normalizer,
+			o.maxNumberOfEndpoints,
+			o.maxNumberOfPeers,
 		),
+		spanKinds: o.spanKinds,
 	}
 }
 
@@ -44,11 +119,17 @@ func (o *Observer) OnEnd(sp sdktrace.ReadOnlySpan) {
 	if operationName == "" {
 		return
 	}
-	if sp.SpanKind() != trace.SpanKindServer {
+	if !o.spanKinds[sp.SpanKind()] {
 		return
 	}
 
-	mets := o.metricsByEndpoint.get(operationName)
+	var mets *Metrics
+	switch sp.SpanKind() {
+	case trace.SpanKindClient, trace.SpanKindProducer:
+		mets = o.metricsByPeer.get(peerOf(sp.Attributes()), operationName)
+	default:
+		mets = o.metricsByEndpoint.get(endpointOf(operationName, sp.Attributes()))
+	}
 	latency := sp.EndTime().Sub(sp.StartTime())
 
 	if status := sp.Status(); status.Code == codes.Error {
@@ -59,7 +140,7 @@ func (o *Observer) OnEnd(sp sdktrace.ReadOnlySpan) {
 		mets.RequestLatencySuccess.Record(latency)
 	}
 	for _, attr := range sp.Attributes() {
-		if string(attr.Key) == string(otelsemconv.HTTPResponseStatusCodeKey) {
+		if attr.Key == otelsemconv.HTTPResponseStatusCodeKey || attr.Key == otelsemconv.HTTPStatusCodeKey {
 			if attr.Value.Type() == attribute.INT64 {
 				mets.recordHTTPStatusCode(attr.Value.AsInt64())
 			} else if attr.Value.Type() == attribute.STRING {
@@ -72,6 +153,31 @@ func (o *Observer) OnEnd(sp sdktrace.ReadOnlySpan) {
 	}
 }
 
+// endpointOf returns the route of a server span, as set by otelmux, falling
+// back to its name.
+func endpointOf(name string, attrs []attribute.KeyValue) string {
+	for _, attr := range attrs {
+		if attr.Key == otelsemconv.HTTPRouteKey && attr.Value.AsString() != "" {
+			return attr.Value.AsString()
+		}
+	}
+	return name
+}
+
+// peerOf returns the dependency called by a client span with attrs.
+func peerOf(attrs []attribute.KeyValue) peer {
+	var p peer
+	for _, attr := range attrs {
+		switch attr.Key {
+		case otelsemconv.PeerServiceKey:
+			p.service = attr.Value.Emit()
+		case otelsemconv.DBSystemKey:
+			p.dbSystem = attr.Value.Emit()
+		}
+	}
+	return p
+}
+
 func (*Observer) Shutdown(context.Context) error {
 	return nil
 }
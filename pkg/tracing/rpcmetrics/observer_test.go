@@ -0,0 +1,159 @@
+package rpcmetrics
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"sample-app/pkg/metrics/metricstest"
+	"sample-app/pkg/otelsemconv"
+)
+
+// endSpan starts and ends a span of the given kind on a tracer feeding
+// observer.
+func endSpan(observer *Observer, name string, kind trace.SpanKind, failed bool, attrs ...attribute.KeyValue) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(observer))
+	_, span := tp.Tracer("test").Start(context.Background(), name,
+		trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+	if failed {
+		span.SetStatus(codes.Error, "failed")
+	}
+	span.End()
+}
+
+func TestObserverServerSpans(t *testing.T) {
+	f := metricstest.NewFactory()
+	observer := NewObserver(f, DefaultNameNormalizer)
+
+	endSpan(observer, "GET /books/{id}", trace.SpanKindServer, true,
+		otelsemconv.HTTPStatusCodeKey.Int(503))
+	endSpan(observer, "GET /books/{id}", trace.SpanKindServer, false,
+		otelsemconv.HTTPResponseStatusCodeKey.String("200"))
+
+	endpoint := map[string]string{"endpoint": "GET_/books/_id_"}
+	with := func(k, v string) map[string]string {
+		tags := map[string]string{k: v}
+		for k, v := range endpoint {
+			tags[k] = v
+		}
+		return tags
+	}
+	f.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "requests", Tags: with("error", "true"), Value: 1},
+		metricstest.ExpectedMetric{Name: "requests", Tags: with("error", "false"), Value: 1},
+		metricstest.ExpectedMetric{Name: "http_requests", Tags: with("status_code", "5xx"), Value: 1},
+		metricstest.ExpectedMetric{Name: "http_requests", Tags: with("status_code", "2xx"), Value: 1},
+		metricstest.ExpectedMetric{Name: "http_requests", Tags: with("status_code", "4xx"), Value: 0},
+	)
+	f.AssertTimerMetrics(t,
+		metricstest.ExpectedMetric{Name: "request_latency", Tags: with("error", "true"), Value: 1},
+		metricstest.ExpectedMetric{Name: "request_latency", Tags: with("error", "false"), Value: 1},
+	)
+}
+
+func TestObserverIgnoresOtherSpans(t *testing.T) {
+	f := metricstest.NewFactory()
+	observer := NewObserver(f, DefaultNameNormalizer)
+
+	endSpan(observer, "query", trace.SpanKindClient, false)
+	endSpan(observer, "work", trace.SpanKindInternal, false)
+	endSpan(observer, "", trace.SpanKindServer, false)
+
+	s := f.Snapshot()
+	delete(s.Counters, "endpoint_overflow_spans")
+	delete(s.Counters, "client.peer_overflow_spans")
+	if len(s.Counters) != 0 || len(s.Timers) != 0 {
+		t.Errorf("got metrics %+v, want none", s)
+	}
+}
+
+func TestObserverClientSpans(t *testing.T) {
+	f := metricstest.NewFactory()
+	observer := NewObserver(f, DefaultNameNormalizer,
+		WithSpanKinds(trace.SpanKindServer, trace.SpanKindClient))
+
+	endSpan(observer, "gorm.Query", trace.SpanKindClient, false, otelsemconv.DBSystemKey.String("sqlite"))
+	endSpan(observer, "gorm.Query", trace.SpanKindClient, true, otelsemconv.DBSystemKey.String("sqlite"))
+	endSpan(observer, "GET", trace.SpanKindClient, false, otelsemconv.PeerServiceKey.String("inventory"))
+	endSpan(observer, "GET /books", trace.SpanKindServer, false)
+
+	f.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{
+			Name:  "client.requests",
+			Tags:  map[string]string{"endpoint": "gorm.Query", "db_system": "sqlite", "peer_service": "", "error": "false"},
+			Value: 1,
+		},
+		metricstest.ExpectedMetric{
+			Name:  "client.requests",
+			Tags:  map[string]string{"endpoint": "gorm.Query", "db_system": "sqlite", "peer_service": "", "error": "true"},
+			Value: 1,
+		},
+		metricstest.ExpectedMetric{
+			Name:  "client.requests",
+			Tags:  map[string]string{"endpoint": "GET", "db_system": "", "peer_service": "inventory", "error": "false"},
+			Value: 1,
+		},
+		metricstest.ExpectedMetric{
+			Name:  "requests",
+			Tags:  map[string]string{"endpoint": "GET_/books", "error": "false"},
+			Value: 1,
+		},
+	)
+}
+
+func TestObserverLimitsEndpoints(t *testing.T) {
+	f := metricstest.NewFactory()
+	observer := NewObserver(f, DefaultNameNormalizer, WithMaxEndpoints(1))
+
+	endSpan(observer, "GET /a", trace.SpanKindServer, false)
+	endSpan(observer, "GET /b", trace.SpanKindServer, false)
+	endSpan(observer, "GET /c", trace.SpanKindServer, false)
+
+	f.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"endpoint": "GET_/a", "error": "false"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"endpoint": "other", "error": "false"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "endpoint_overflow_spans", Value: 2},
+	)
+}
+
+func TestObserverLimitsPeers(t *testing.T) {
+	f := metricstest.NewFactory()
+	observer := NewObserver(f, DefaultNameNormalizer,
+		WithSpanKinds(trace.SpanKindClient), WithMaxPeers(1))
+
+	endSpan(observer, "GET", trace.SpanKindClient, false, otelsemconv.PeerServiceKey.String("inventory"))
+	endSpan(observer, "GET", trace.SpanKindClient, false, otelsemconv.PeerServiceKey.String("pricing"))
+	endSpan(observer, "GET", trace.SpanKindClient, false, otelsemconv.PeerServiceKey.String("search"))
+
+	f.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{
+			Name:  "client.requests",
+			Tags:  map[string]string{"endpoint": "GET", "db_system": "", "peer_service": "inventory", "error": "false"},
+			Value: 1,
+		},
+		metricstest.ExpectedMetric{
+			Name:  "client.requests",
+			Tags:  map[string]string{"endpoint": "GET", "db_system": "other", "peer_service": "other", "error": "false"},
+			Value: 2,
+		},
+		metricstest.ExpectedMetric{Name: "client.peer_overflow_spans", Value: 2},
+	)
+}
+
+func TestObserverPrefersRoute(t *testing.T) {
+	f := metricstest.NewFactory()
+	observer := NewObserver(f, DefaultRouteNameNormalizer)
+
+	endSpan(observer, "GET /books/1", trace.SpanKindServer, false,
+		otelsemconv.HTTPRouteKey.String("/books/{id}"))
+	endSpan(observer, "GET /books/2", trace.SpanKindServer, false)
+
+	f.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"endpoint": "/books/_id_", "error": "false"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "requests", Tags: map[string]string{"endpoint": "GET_/books/_id_", "error": "false"}, Value: 1},
+	)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sample-app/config"
+	"sample-app/handlers"
+	"sample-app/models"
+	"sample-app/pkg/log"
+	"sample-app/pkg/tracing"
+	"sample-app/services"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+)
+
+// telemetryShutdownTimeout bounds flushing the last spans and metrics on
+// shutdown.
+const telemetryShutdownTimeout = 5 * time.Second
+
+// serve runs the book service until it receives SIGINT or SIGTERM, then
+// drains and shuts it down: readiness turns false for the drain period,
+// in-flight requests get until the shutdown timeout to complete, and
+// finally spans and metrics are flushed and the database is closed. If the
+// server cannot listen or fails while serving, it is released the same way
+// and the error is returned.
+func serve(logger log.Factory, cfg *config.Config) error {
+	// Initialize metrics
+	mets, err := initMetrics(cfg)
+	if err != nil {
+		logger.Bg().Fatal("Failed to initialize metrics", zap.String("exporter", cfg.Metrics.Exporter), zap.Error(err))
+	}
+
+	// Initialize tracer
+	tp := tracing.InitOTEL(cfg.ServiceName, tracing.ExporterOptions{
+		Type:     cfg.Tracing.Exporter,
+		Endpoint: cfg.Tracing.Endpoint,
+		Headers:  cfg.Tracing.Headers,
+	}, tracing.RPCMetricsOptions{
+		SpanKinds:    cfg.Metrics.SpanKinds,
+		MaxEndpoints: cfg.Metrics.MaxEndpoints,
+		MaxPeers:     cfg.Metrics.MaxPeers,
+		Normalizer:   cfg.Metrics.EndpointNormalizer,
+	}, mets.Factory, logger)
+	otel.SetTracerProvider(tp)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), telemetryShutdownTimeout)
+		defer cancel()
+		if err := tp.Shutdown(ctx); err != nil {
+			logger.Bg().Error("Error shutting down tracer provider", zap.Error(err))
+		}
+		// after the tracer provider, since the RPC metrics are recorded when
+		// spans end
+		if err := mets.Shutdown(ctx); err != nil {
+			logger.Bg().Error("Error shutting down metrics", zap.Error(err))
+		}
+		if err := models.CloseDatabase(); err != nil {
+			logger.Bg().Error("Error closing database", zap.Error(err))
+		}
+		logger.Bg().Info("Server stopped")
+	}()
+
+	// Set up book storage
+	var store models.Interface
+	var checks []handlers.HealthCheck
+	switch cfg.Store.Type {
+	case "sqlite":
+		applied, err := models.ConnectDatabase(context.Background(), cfg.Store.Path)
+		for _, m := range applied {
+			logger.Bg().Info("Applied migration", zap.Int64("version", m.Version), zap.String("name", m.Name))
+		}
+		if err != nil {
+			logger.Bg().Fatal("Failed to connect to database", zap.Error(withUnlockHint(err)))
+		}
+		store = models.NewSQLStore(models.DB)
+		checks = append(checks,
+			handlers.HealthCheck{Name: "database", Check: models.PingDatabase},
+			handlers.HealthCheck{Name: "migrations", Check: models.CheckMigrations})
+	case "memory":
+		store = models.NewMemoryStore()
+	default:
+		logger.Bg().Fatal("unrecognized store type", zap.String("store", cfg.Store.Type))
+	}
+
+	// Initialize services and handlers
+	bookService := services.NewBookService(store, time.Duration(cfg.Trash.Retention))
+	bookHandler := handlers.NewBookHandler(bookService)
+	authorService := services.NewAuthorService(store)
+	authorHandler := handlers.NewAuthorHandler(authorService, bookService)
+	health := handlers.NewHealth(checks...)
+
+	// Set up router with OpenTelemetry instrumentation, probes and metrics
+	// scrapes are not traced
+	r := mux.NewRouter()
+	r.Use(otelmux.Middleware(cfg.ServiceName, otelmux.WithFilter(traced)))
+	r.Use(handlers.Actor)
+
+	// Register routes
+	r.HandleFunc("/books", bookHandler.CreateBook).Methods("POST")
+	r.HandleFunc("/books", bookHandler.ListBooks).Methods("GET")
+	r.HandleFunc("/books/search", bookHandler.SearchBooks).Methods("GET")
+	r.HandleFunc("/books/trash", bookHandler.ListTrash).Methods("GET")
+	r.HandleFunc("/books/trash", bookHandler.PurgeTrash).Methods("DELETE")
+	r.HandleFunc("/books/{id}", bookHandler.GetBook).Methods("GET")
+	r.HandleFunc("/books/{id}", bookHandler.UpdateBook).Methods("PUT")
+	r.HandleFunc("/books/{id}", bookHandler.PatchBook).Methods("PATCH")
+	r.HandleFunc("/books/{id}", bookHandler.DeleteBook).Methods("DELETE")
+	r.HandleFunc("/books/{id}/restore", bookHandler.RestoreBook).Methods("POST")
+	r.HandleFunc("/books/{id}/history", bookHandler.ListBookHistory).Methods("GET")
+	r.HandleFunc("/books/{id}/history/{revision}", bookHandler.GetBookRevision).Methods("GET")
+	r.HandleFunc("/authors", authorHandler.CreateAuthor).Methods("POST")
+	r.HandleFunc("/authors", authorHandler.ListAuthors).Methods("GET")
+	r.HandleFunc("/authors/{id}", authorHandler.GetAuthor).Methods("GET")
+	r.HandleFunc("/authors/{id}", authorHandler.UpdateAuthor).Methods("PUT")
+	r.HandleFunc("/authors/{id}", authorHandler.DeleteAuthor).Methods("DELETE")
+	r.HandleFunc("/authors/{id}/books", authorHandler.ListAuthorBooks).Methods("GET")
+	r.HandleFunc("/tags", bookHandler.ListTags).Methods("GET")
+	r.HandleFunc("/healthz", health.Live).Methods("GET")
+	r.HandleFunc("/readyz", health.Ready).Methods("GET")
+	r.HandleFunc("/health", health.Detailed).Methods("GET")
+	if mets.Handler != nil {
+		r.Handle(mets.Path, mets.Handler).Methods("GET")
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start server, listening first so that a busy port is reported
+	// before the server claims to be running
+	ln, err := net.Listen("tcp", cfg.Server.Addr)
+	if err != nil {
+		return err
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+	logger.Bg().Info("Server is running",
+		zap.String("addr", ln.Addr().String()),
+		zap.String("store", cfg.Store.Type),
+		zap.String("traces-exporter", cfg.Tracing.Exporter))
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+		// a second signal kills the process without waiting
+		stop()
+		shutdown(logger, srv, health, cfg.Server)
+	}
+	return nil
+}
+
+// traced reports whether r is traced. Health probes and metrics scrapes
+// are left out of traces and, since they are computed from spans, of RPC
+// metrics.
+func traced(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/healthz", "/readyz", "/health", "/metrics", "/debug/vars":
+		return false
+	}
+	return true
+}
+
+// shutdown drains srv and waits for its in-flight requests to complete,
+// closing the remaining connections once the shutdown timeout expires.
+func shutdown(logger log.Factory, srv *http.Server, health *handlers.Health, cfg config.ServerConfig) {
+	drain := time.Duration(cfg.DrainPeriod)
+	logger.Bg().Info("Draining server", zap.Duration("drain-period", drain))
+	health.Drain()
+	time.Sleep(drain)
+
+	logger.Bg().Info("Shutting down server", zap.Duration("timeout", time.Duration(cfg.ShutdownTimeout)))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeout))
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Bg().Error("Requests did not complete before the shutdown timeout", zap.Error(err))
+		if err := srv.Close(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Bg().Error("Error closing server", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+)
+
+// AnonymousActor is recorded in the history of changes made by unknown callers.
+const AnonymousActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the name of who is making changes,
+// which is recorded in the history of the books they change.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or AnonymousActor.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
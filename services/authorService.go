@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"sample-app/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type AuthorService struct {
+	tracer trace.Tracer
+	store  models.Interface
+}
+
+func NewAuthorService(store models.Interface) *AuthorService {
+	return &AuthorService{
+		tracer: otel.Tracer("author-service"),
+		store:  store,
+	}
+}
+
+// AuthorListOptions controls pagination and filtering in AuthorService.ListAuthors.
+type AuthorListOptions struct {
+	// Limit is the page size, DefaultPageSize if zero.
+	Limit  int
+	Offset int
+	// NamePrefix filters on a case-insensitive prefix of the name.
+	NamePrefix string
+}
+
+// AuthorList is a page of authors returned by AuthorService.ListAuthors.
+type AuthorList struct {
+	Authors []models.Author
+	// HasMore reports whether more authors follow this page.
+	HasMore bool
+}
+
+// CreateAuthor adds an author. It fails with ErrConflict if an author with
+// the same name key exists.
+func (s *AuthorService) CreateAuthor(ctx context.Context, author *models.Author) error {
+	ctx, span := s.tracer.Start(ctx, "CreateAuthor")
+	defer span.End()
+
+	author.ResetServerFields()
+	author.Normalize()
+	span.SetAttributes(attribute.String("author.name", author.Name))
+
+	if err := author.Validate(); err != nil {
+		span.RecordError(err)
+		return &Error{Op: "create author", Kind: ErrValidation, Err: err}
+	}
+
+	if err := s.store.CreateAuthor(ctx, author); err != nil {
+		span.RecordError(err)
+		return wrapError("create author", err)
+	}
+
+	span.SetAttributes(attribute.Int64("author.id", int64(author.ID)))
+	return nil
+}
+
+func (s *AuthorService) GetAuthor(ctx context.Context, id uint) (*models.Author, error) {
+	ctx, span := s.tracer.Start(ctx, "GetAuthor")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("author.id", int64(id)))
+
+	author, err := s.store.GetAuthor(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError(fmt.Sprintf("get author %d", id), err)
+	}
+
+	return author, nil
+}
+
+// ListAuthors returns a page of authors ordered by name.
+func (s *AuthorService) ListAuthors(ctx context.Context, opts AuthorListOptions) (*AuthorList, error) {
+	ctx, span := s.tracer.Start(ctx, "ListAuthors")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("list.limit", opts.Limit),
+		attribute.Int("list.offset", opts.Offset),
+		attribute.String("list.filter.name_prefix", opts.NamePrefix),
+	)
+
+	q := models.AuthorQuery{NamePrefix: opts.NamePrefix, Offset: opts.Offset, Limit: opts.Limit}
+	var err error
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultPageSize
+	case q.Limit < 0 || q.Limit > MaxPageSize:
+		err = fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	}
+	if q.Offset < 0 {
+		err = errors.New("offset must not be negative")
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, &Error{Op: "list authors", Kind: ErrValidation, Err: err}
+	}
+
+	page, err := s.store.ListAuthors(ctx, q)
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError("list authors", err)
+	}
+
+	list := &AuthorList{Authors: page.Authors, HasMore: page.HasMore}
+	if list.Authors == nil {
+		list.Authors = []models.Author{}
+	}
+
+	span.SetAttributes(
+		attribute.Int("authors.count", len(list.Authors)),
+		attribute.Bool("list.has_more", list.HasMore),
+	)
+	return list, nil
+}
+
+// UpdateAuthor renames an author. The bylines of their books are left as
+// credited.
+func (s *AuthorService) UpdateAuthor(ctx context.Context, author *models.Author) error {
+	ctx, span := s.tracer.Start(ctx, "UpdateAuthor")
+	defer span.End()
+
+	author.Normalize()
+	span.SetAttributes(
+		attribute.Int64("author.id", int64(author.ID)),
+		attribute.String("author.name", author.Name),
+	)
+
+	op := fmt.Sprintf("update author %d", author.ID)
+	if err := author.Validate(); err != nil {
+		span.RecordError(err)
+		return &Error{Op: op, Kind: ErrValidation, Err: err}
+	}
+
+	if err := s.store.UpdateAuthor(ctx, author); err != nil {
+		span.RecordError(err)
+		return wrapError(op, err)
+	}
+
+	return nil
+}
+
+// DeleteAuthor removes an author. It fails with ErrConflict while the
+// author is credited for books, including books in the trash.
+func (s *AuthorService) DeleteAuthor(ctx context.Context, id uint) error {
+	ctx, span := s.tracer.Start(ctx, "DeleteAuthor")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("author.id", int64(id)))
+
+	if err := s.store.DeleteAuthor(ctx, id); err != nil {
+		span.RecordError(err)
+		return wrapError(fmt.Sprintf("delete author %d", id), err)
+	}
+
+	return nil
+}
+
+// resolveAuthors looks up the authors listed by book, creating the ones
+// given by name that do not exist yet, and replaces book.Authors with them.
+// Without a list, the authors are taken from the byline; without a byline,
+// it is built from the authors' names.
+func resolveAuthors(ctx context.Context, tx models.Interface, book *models.Book) error {
+	listed := book.Authors
+	if len(listed) == 0 {
+		names, ok := models.SplitAuthors(book.Author)
+		if !ok {
+			// rather no authors than made up ones, they can still be listed
+			trace.SpanFromContext(ctx).AddEvent("authors not linked to an ambiguous byline",
+				trace.WithAttributes(attribute.String("book.author", book.Author)))
+		}
+		for _, name := range names {
+			listed = append(listed, models.Author{Name: name})
+		}
+	}
+
+	authors := make([]models.Author, 0, len(listed))
+	for i, author := range listed {
+		if author.ID != 0 {
+			found, err := tx.GetAuthor(ctx, author.ID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.ValidationError{{Field: fmt.Sprintf("authors[%d].id", i), Message: "does not exist"}}
+			}
+			if err != nil {
+				return err
+			}
+			author = *found
+		} else {
+			author = models.Author{Name: author.Name}
+			if err := tx.FirstOrCreateAuthor(ctx, &author); err != nil {
+				return err
+			}
+		}
+		if !slices.ContainsFunc(authors, func(a models.Author) bool { return a.ID == author.ID }) {
+			authors = append(authors, author)
+		}
+	}
+
+	book.Authors = authors
+	if book.Author == "" {
+		book.Author = models.JoinAuthors(authors)
+	}
+	return nil
+}
+
+// linkAuthors links a stored book to its resolved authors.
+func linkAuthors(ctx context.Context, tx models.Interface, book *models.Book) error {
+	ids := make([]uint, len(book.Authors))
+	for i, author := range book.Authors {
+		ids[i] = author.ID
+	}
+	return tx.SetBookAuthors(ctx, book.ID, ids)
+}
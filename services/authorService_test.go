@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sample-app/models"
+)
+
+func TestCreateAuthorIgnoresServerFields(t *testing.T) {
+	ctx := context.Background()
+	s := NewAuthorService(models.NewMemoryStore())
+
+	created := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	author := &models.Author{ID: 77, Name: "Ursula K. Le Guin", CreatedAt: created, UpdatedAt: created}
+	if err := s.CreateAuthor(ctx, author); err != nil {
+		t.Fatal(err)
+	}
+	if author.ID == 77 || author.CreatedAt.Equal(created) {
+		t.Errorf("got id %d created at %v, want a new id and creation time", author.ID, author.CreatedAt)
+	}
+	if _, err := s.GetAuthor(ctx, author.ID); err != nil {
+		t.Errorf("created author is not readable: %v", err)
+	}
+}
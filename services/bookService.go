@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"sample-app/models"
 
@@ -12,13 +13,26 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// DefaultTrashRetention is how long deleted books are kept before
+// PurgeDeletedBooks removes them, unless the service is configured otherwise.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
 type BookService struct {
-	tracer trace.Tracer
+	tracer    trace.Tracer
+	store     models.Interface
+	retention time.Duration
 }
 
-func NewBookService() *BookService {
+// NewBookService creates a BookService keeping deleted books in the trash
+// for trashRetention, DefaultTrashRetention if zero.
+func NewBookService(store models.Interface, trashRetention time.Duration) *BookService {
+	if trashRetention == 0 {
+		trashRetention = DefaultTrashRetention
+	}
 	return &BookService{
-		tracer: otel.Tracer("book-service"),
+		tracer:    otel.Tracer("book-service"),
+		store:     store,
+		retention: trashRetention,
 	}
 }
 
@@ -26,84 +40,277 @@ func (s *BookService) CreateBook(ctx context.Context, book *models.Book) error {
 	ctx, span := s.tracer.Start(ctx, "CreateBook")
 	defer span.End()
 
+	book.ResetServerFields()
+	book.Normalize()
 	span.SetAttributes(
 		attribute.String("book.title", book.Title),
 		attribute.String("book.author", book.Author),
 	)
 
-	result := models.DB.WithContext(ctx).Create(book)
-	if result.Error != nil {
-		span.RecordError(result.Error)
-		return fmt.Errorf("failed to create book: %v", result.Error)
+	if err := book.Validate(); err != nil {
+		span.RecordError(err)
+		return &Error{Op: "create book", Kind: ErrValidation, Err: err}
+	}
+
+	err := s.store.Transaction(ctx, func(tx models.Interface) error {
+		if err := resolveAuthors(ctx, tx, book); err != nil {
+			return err
+		}
+		if err := tx.CreateBook(ctx, book); err != nil {
+			return err
+		}
+		if err := linkAuthors(ctx, tx, book); err != nil {
+			return err
+		}
+		if err := linkTags(ctx, tx, book); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, models.ActionCreate, nil, book)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return wrapError("create book", err)
 	}
 
 	return nil
 }
 
-func (s *BookService) GetBook(ctx context.Context, id uint) (*models.Book, error) {
+// GetBook returns a book. Deleted books are only returned if includeDeleted is set.
+func (s *BookService) GetBook(ctx context.Context, id uint, includeDeleted bool) (*models.Book, error) {
 	ctx, span := s.tracer.Start(ctx, "GetBook")
 	defer span.End()
 
-	span.SetAttributes(attribute.Int64("book.id", int64(id)))
+	span.SetAttributes(
+		attribute.Int64("book.id", int64(id)),
+		attribute.Bool("book.include_deleted", includeDeleted),
+	)
 
-	var book models.Book
-	result := models.DB.WithContext(ctx).First(&book, id)
-	if result.Error != nil {
-		span.RecordError(result.Error)
-		if errors.Is(result.Error, models.DB.Error) {
-			return nil, fmt.Errorf("book not found: %d", id)
-		}
-		return nil, fmt.Errorf("failed to get book: %v", result.Error)
+	visibility := models.ActiveBooks
+	if includeDeleted {
+		visibility = models.AllBooks
+	}
+	book, err := s.store.GetBook(ctx, id, visibility)
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError(fmt.Sprintf("get book %d", id), err)
 	}
 
-	return &book, nil
+	return book, nil
 }
 
-func (s *BookService) ListBooks(ctx context.Context) ([]models.Book, error) {
+func (s *BookService) ListBooks(ctx context.Context, opts ListOptions) (*BookList, error) {
 	ctx, span := s.tracer.Start(ctx, "ListBooks")
 	defer span.End()
 
-	var books []models.Book
-	result := models.DB.WithContext(ctx).Find(&books)
-	if result.Error != nil {
-		span.RecordError(result.Error)
-		return nil, fmt.Errorf("failed to list books: %v", result.Error)
+	span.SetAttributes(
+		attribute.Int("list.limit", opts.Limit),
+		attribute.Int("list.offset", opts.Offset),
+		attribute.Bool("list.cursor", opts.Cursor != ""),
+		attribute.String("list.sort", opts.Sort),
+		attribute.String("list.filter.title", opts.Title),
+		attribute.String("list.filter.title_prefix", opts.TitlePrefix),
+		attribute.String("list.filter.author", opts.Author),
+		attribute.String("list.filter.author_prefix", opts.AuthorPrefix),
+		attribute.Int64("list.filter.author_id", int64(opts.AuthorID)),
+		attribute.String("list.filter.genre", opts.Genre),
+		attribute.String("list.filter.tag", opts.Tag),
+		attribute.Bool("list.include_total", opts.IncludeTotal),
+		attribute.Bool("list.include_deleted", opts.IncludeDeleted),
+		attribute.Bool("list.only_deleted", opts.OnlyDeleted),
+	)
+
+	q, err := opts.toQuery()
+	if err != nil {
+		span.RecordError(err)
+		return nil, &Error{Op: "list books", Kind: ErrValidation, Err: err}
+	}
+
+	page, err := s.store.ListBooks(ctx, q)
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError("list books", err)
+	}
+
+	list := &BookList{Books: page.Books}
+	if list.Books == nil {
+		list.Books = []models.Book{}
+	}
+	if opts.IncludeTotal {
+		list.Total = &page.Total
+		span.SetAttributes(attribute.Int64("books.total", page.Total))
+	}
+	if page.HasMore {
+		last := &page.Books[len(page.Books)-1]
+		list.NextCursor = encodeCursor(opts.sortOrder(), models.CursorOf(last, q.SortBy))
+	}
+
+	span.SetAttributes(
+		attribute.Int("books.count", len(list.Books)),
+		attribute.Bool("list.has_more", page.HasMore),
+	)
+	return list, nil
+}
+
+// SearchBooks finds books whose title or author contain words starting with
+// the words of query, best matches first.
+func (s *BookService) SearchBooks(ctx context.Context, query string, limit int) ([]models.SearchResult, error) {
+	ctx, span := s.tracer.Start(ctx, "SearchBooks")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("search.query", query),
+		attribute.Int("search.limit", limit),
+	)
+
+	q, err := newSearchQuery(query, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, &Error{Op: "search books", Kind: ErrValidation, Err: err}
+	}
+
+	results, err := s.store.SearchBooks(ctx, q)
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError("search books", err)
 	}
 
-	span.SetAttributes(attribute.Int("books.count", len(books)))
-	return books, nil
+	span.SetAttributes(attribute.Int("books.count", len(results)))
+	return results, nil
 }
 
+// UpdateBook replaces the stored book. If book.Version is non-zero, the
+// update only succeeds while it is still the stored version. Books that do
+// not list their authors are linked to the authors named in the byline.
 func (s *BookService) UpdateBook(ctx context.Context, book *models.Book) error {
 	ctx, span := s.tracer.Start(ctx, "UpdateBook")
 	defer span.End()
 
+	// only the ID and the expected version are taken from the caller
+	id, version := book.ID, book.Version
+	book.ResetServerFields()
+	book.ID, book.Version = id, version
+	book.Normalize()
 	span.SetAttributes(
 		attribute.Int64("book.id", int64(book.ID)),
+		attribute.Int64("book.version", int64(book.Version)),
 		attribute.String("book.title", book.Title),
 		attribute.String("book.author", book.Author),
 	)
 
-	result := models.DB.WithContext(ctx).Save(book)
-	if result.Error != nil {
-		span.RecordError(result.Error)
-		return fmt.Errorf("failed to update book: %v", result.Error)
+	if err := book.Validate(); err != nil {
+		span.RecordError(err)
+		return &Error{Op: fmt.Sprintf("update book %d", book.ID), Kind: ErrValidation, Err: err}
+	}
+
+	err := s.store.Transaction(ctx, func(tx models.Interface) error {
+		before, err := tx.GetBook(ctx, book.ID, models.ActiveBooks)
+		if err != nil {
+			return err
+		}
+		if err := resolveAuthors(ctx, tx, book); err != nil {
+			return err
+		}
+		if err := tx.UpdateBook(ctx, book); err != nil {
+			return err
+		}
+		if err := linkAuthors(ctx, tx, book); err != nil {
+			return err
+		}
+		if err := linkTags(ctx, tx, book); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, models.ActionUpdate, before, book)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return wrapError(fmt.Sprintf("update book %d", book.ID), err)
 	}
 
 	return nil
 }
 
-func (s *BookService) DeleteBook(ctx context.Context, id uint) error {
+// DeleteBook moves a book to the trash. If version is non-zero, the book is
+// only deleted while it is still the stored version.
+func (s *BookService) DeleteBook(ctx context.Context, id uint, version uint) error {
 	ctx, span := s.tracer.Start(ctx, "DeleteBook")
 	defer span.End()
 
-	span.SetAttributes(attribute.Int64("book.id", int64(id)))
+	span.SetAttributes(
+		attribute.Int64("book.id", int64(id)),
+		attribute.Int64("book.version", int64(version)),
+	)
 
-	result := models.DB.WithContext(ctx).Delete(&models.Book{}, id)
-	if result.Error != nil {
-		span.RecordError(result.Error)
-		return fmt.Errorf("failed to delete book: %v", result.Error)
+	err := s.store.Transaction(ctx, func(tx models.Interface) error {
+		before, err := tx.GetBook(ctx, id, models.ActiveBooks)
+		if err != nil {
+			return err
+		}
+		if err := tx.DeleteBook(ctx, id, version); err != nil {
+			return err
+		}
+		after, err := tx.GetBook(ctx, id, models.DeletedBooks)
+		if err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, models.ActionDelete, before, after)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return wrapError(fmt.Sprintf("delete book %d", id), err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// RestoreBook brings a deleted book back from the trash.
+func (s *BookService) RestoreBook(ctx context.Context, id uint) (*models.Book, error) {
+	ctx, span := s.tracer.Start(ctx, "RestoreBook")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("book.id", int64(id)))
+
+	var book *models.Book
+	err := s.store.Transaction(ctx, func(tx models.Interface) error {
+		before, err := tx.GetBook(ctx, id, models.DeletedBooks)
+		if err != nil {
+			return err
+		}
+		if book, err = tx.RestoreBook(ctx, id); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, models.ActionRestore, before, book)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError(fmt.Sprintf("restore book %d", id), err)
+	}
+
+	return book, nil
+}
+
+// PurgeDeletedBooks permanently removes the books that have been in the
+// trash for longer than retention, the configured retention if zero. It
+// returns the number of purged books. Their history is kept.
+func (s *BookService) PurgeDeletedBooks(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, span := s.tracer.Start(ctx, "PurgeDeletedBooks")
+	defer span.End()
+
+	if retention == 0 {
+		retention = s.retention
+	}
+	span.SetAttributes(attribute.String("trash.retention", retention.String()))
+	if retention < 0 {
+		err := errors.New("retention must not be negative")
+		span.RecordError(err)
+		return 0, &Error{Op: "purge deleted books", Kind: ErrValidation, Err: err}
+	}
+
+	purged, err := s.store.PurgeBooks(ctx, time.Now().Add(-retention))
+	if err != nil {
+		span.RecordError(err)
+		return 0, wrapError("purge deleted books", err)
+	}
+
+	span.SetAttributes(attribute.Int64("books.purged", purged))
+	return purged, nil
+}
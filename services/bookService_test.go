@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sample-app/models"
+
+	"gorm.io/gorm"
+)
+
+func TestBookServiceUsesStore(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewMemoryStore()
+	s := NewBookService(store, 0)
+
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert"}
+	if err := s.CreateBook(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := store.GetBook(ctx, book.ID, models.ActiveBooks); err != nil || stored.Title != "Dune" {
+		t.Fatalf("got %+v, %v from the store, want the created book", stored, err)
+	}
+	list, err := s.ListBooks(ctx, ListOptions{})
+	if err != nil || len(list.Books) != 1 {
+		t.Errorf("got books %+v, %v; want the created book", list, err)
+	}
+	if err := s.DeleteBook(ctx, book.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetBook(ctx, book.ID, false); err == nil {
+		t.Error("expected an error getting a deleted book")
+	}
+}
+
+func TestBookServiceValidates(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewMemoryStore()
+	s := NewBookService(store, 0)
+
+	err := s.CreateBook(ctx, &models.Book{Title: "  ", Author: "Frank Herbert"})
+	var verr models.ValidationError
+	if !errors.Is(err, ErrValidation) || !errors.As(err, &verr) || verr[0].Field != "title" {
+		t.Fatalf("got %v, want a validation error on title", err)
+	}
+
+	book := &models.Book{Title: " Dune ", Author: "Frank Herbert"}
+	if err := s.CreateBook(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.GetBook(ctx, book.ID, models.ActiveBooks); stored.Title != "Dune" {
+		t.Errorf("got title %q stored, want it trimmed", stored.Title)
+	}
+	if err := s.UpdateBook(ctx, &models.Book{ID: book.ID, Title: "Dune"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("got %v updating without an author, want ErrValidation", err)
+	}
+}
+
+func TestCreateBookIgnoresServerFields(t *testing.T) {
+	ctx := context.Background()
+	s := NewBookService(models.NewMemoryStore(), 0)
+
+	book := &models.Book{
+		ID:        77,
+		Title:     "The Hobbit",
+		Author:    "J. R. R. Tolkien",
+		Version:   9,
+		UpdatedAt: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		DeletedAt: gorm.DeletedAt{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+	}
+	if err := s.CreateBook(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+	if book.ID == 77 || book.Version != 1 || book.DeletedAt.Valid {
+		t.Errorf("got id %d, version %d, deleted_at %v; want a new id, version 1 and no deleted_at",
+			book.ID, book.Version, book.DeletedAt)
+	}
+	if _, err := s.GetBook(ctx, book.ID, false); err != nil {
+		t.Errorf("created book is not readable: %v", err)
+	}
+}
+
+func TestUpdateBookIgnoresDeletedAt(t *testing.T) {
+	ctx := context.Background()
+	s := NewBookService(models.NewMemoryStore(), 0)
+
+	book := &models.Book{Title: "The Hobbit", Author: "J. R. R. Tolkien"}
+	if err := s.CreateBook(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+	update := &models.Book{
+		ID:        book.ID,
+		Title:     "The Hobbit, or There and Back Again",
+		Author:    "J. R. R. Tolkien",
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true},
+	}
+	if err := s.UpdateBook(ctx, update); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetBook(ctx, book.ID, false)
+	if err != nil {
+		t.Fatalf("updated book is not readable: %v", err)
+	}
+	if got.Title != update.Title || got.Version != 2 {
+		t.Errorf("got title %q version %d, want %q version 2", got.Title, got.Version, update.Title)
+	}
+}
+
+func TestCreateBookLeavesAmbiguousBylineUnlinked(t *testing.T) {
+	ctx := context.Background()
+	store := models.NewMemoryStore()
+	s := NewBookService(store, 0)
+
+	book := &models.Book{Title: "Harry Potter", Author: "Rowling, J.K."}
+	if err := s.CreateBook(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+	if len(book.Authors) != 0 {
+		t.Errorf("got authors %v, want none", book.Authors)
+	}
+	page, err := store.ListAuthors(ctx, models.AuthorQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Authors) != 0 {
+		t.Errorf("got authors %v created, want none", page.Authors)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"sample-app/models"
+
+	"gorm.io/gorm"
+)
+
+// Sentinel errors describing why a service operation failed.
+// Use errors.Is to test for them.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("unavailable")
+	// ErrPreconditionFailed means the book is not at the version the caller expected.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is returned by service operations. It matches its Kind with
+// errors.Is and unwraps to the underlying storage error.
+type Error struct {
+	// Op describes the failed operation, e.g. "get book".
+	Op string
+	// Kind is one of the sentinel errors, or nil for unexpected failures.
+	Kind error
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return "failed to " + e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of this error.
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// wrapError classifies a storage error and wraps it into an *Error.
+// Errors that already are an *Error are returned unchanged.
+func wrapError(op string, err error) error {
+	var serr *Error
+	if errors.As(err, &serr) {
+		return err
+	}
+	return &Error{Op: op, Kind: kindOf(err), Err: err}
+}
+
+func kindOf(err error) error {
+	for _, kind := range []error{ErrNotFound, ErrConflict, ErrValidation, ErrUnavailable, ErrPreconditionFailed} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	var verr models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return ErrValidation
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	case errors.Is(err, models.ErrVersionConflict):
+		return ErrPreconditionFailed
+	case errors.Is(err, gorm.ErrDuplicatedKey),
+		errors.Is(err, gorm.ErrForeignKeyViolated),
+		errors.Is(err, models.ErrAuthorInUse),
+		errors.Is(err, models.ErrDuplicateISBN):
+		return ErrConflict
+	case errors.Is(err, gorm.ErrInvalidData),
+		errors.Is(err, gorm.ErrInvalidValue),
+		errors.Is(err, gorm.ErrPrimaryKeyRequired):
+		return ErrValidation
+	case errors.Is(err, gorm.ErrInvalidDB),
+		errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled):
+		return ErrUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestWrapErrorClassifies(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{gorm.ErrRecordNotFound, ErrNotFound},
+		{gorm.ErrDuplicatedKey, ErrConflict},
+		{gorm.ErrInvalidData, ErrValidation},
+		{fmt.Errorf("query: %w", driver.ErrBadConn), ErrUnavailable},
+		{context.DeadlineExceeded, ErrUnavailable},
+		{fmt.Errorf("store: %w", ErrConflict), ErrConflict},
+	}
+	for _, tt := range tests {
+		err := wrapError("get book 1", tt.err)
+		if !errors.Is(err, tt.kind) {
+			t.Errorf("%v: got %v, want kind %v", tt.err, err, tt.kind)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%v: the storage error is not unwrapped", tt.err)
+		}
+	}
+
+	err := wrapError("get book 1", errors.New("disk on fire"))
+	for _, kind := range []error{ErrNotFound, ErrConflict, ErrValidation, ErrUnavailable} {
+		if errors.Is(err, kind) {
+			t.Errorf("an unexpected failure matches %v", kind)
+		}
+	}
+	if got := err.Error(); got != "failed to get book 1: disk on fire" {
+		t.Errorf("got message %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"sample-app/models"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordChange appends a change to the history of a book within the
+// transaction tx, attributing it to the actor and trace of ctx.
+func recordChange(ctx context.Context, tx models.Interface, action string, before, after *models.Book) error {
+	rev := &models.BookRevision{
+		BookID: after.ID,
+		Action: action,
+		Actor:  ActorFromContext(ctx),
+		Before: before,
+		After:  after,
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		rev.TraceID = sc.TraceID().String()
+	}
+	if err := tx.AddRevision(ctx, rev); err != nil {
+		return err
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("book.revision", int64(rev.Revision)))
+	return nil
+}
+
+// ListBookHistory returns every recorded change of a book, oldest first.
+// The history outlives the book itself.
+func (s *BookService) ListBookHistory(ctx context.Context, id uint) ([]models.BookRevision, error) {
+	ctx, span := s.tracer.Start(ctx, "ListBookHistory")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("book.id", int64(id)))
+
+	op := fmt.Sprintf("list history of book %d", id)
+	revs, err := s.store.ListRevisions(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError(op, err)
+	}
+	if len(revs) == 0 {
+		return nil, &Error{Op: op, Kind: ErrNotFound, Err: fmt.Errorf("book %d has no history", id)}
+	}
+
+	span.SetAttributes(attribute.Int("book.revisions", len(revs)))
+	return revs, nil
+}
+
+// GetBookRevision returns a single change of a book. Its After snapshot
+// is the book as of that revision.
+func (s *BookService) GetBookRevision(ctx context.Context, id uint, revision uint) (*models.BookRevision, error) {
+	ctx, span := s.tracer.Start(ctx, "GetBookRevision")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("book.id", int64(id)),
+		attribute.Int64("book.revision", int64(revision)),
+	)
+
+	rev, err := s.store.GetRevision(ctx, id, revision)
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError(fmt.Sprintf("get revision %d of book %d", revision, id), err)
+	}
+	return rev, nil
+}
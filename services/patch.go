@@ -0,0 +1,118 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"sample-app/models"
+	"sample-app/pkg/jsonpatch"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Patch transforms the JSON representation of a book, e.g. a
+// jsonpatch.MergePatch or jsonpatch.Patch.
+type Patch interface {
+	Apply(doc []byte) ([]byte, error)
+}
+
+// PatchBook applies the patch to the stored book and saves the result.
+// Fields the patch does not touch are left unchanged. If version is
+// non-zero, the patch is only applied to that version of the book.
+func (s *BookService) PatchBook(ctx context.Context, id uint, version uint, patch Patch) (*models.Book, error) {
+	ctx, span := s.tracer.Start(ctx, "PatchBook")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("book.id", int64(id)),
+		attribute.Int64("book.version", int64(version)),
+		attribute.String("patch.type", fmt.Sprintf("%T", patch)),
+	)
+
+	op := fmt.Sprintf("patch book %d", id)
+	var book *models.Book
+	err := s.store.Transaction(ctx, func(tx models.Interface) error {
+		current, err := tx.GetBook(ctx, id, models.ActiveBooks)
+		if err != nil {
+			return err
+		}
+		if version != 0 && version != current.Version {
+			return models.ErrVersionConflict
+		}
+		book, err = applyPatch(op, current, patch)
+		if err != nil {
+			return err
+		}
+		if err := resolveAuthors(ctx, tx, book); err != nil {
+			return err
+		}
+		if err := tx.UpdateBook(ctx, book); err != nil {
+			return err
+		}
+		if err := linkAuthors(ctx, tx, book); err != nil {
+			return err
+		}
+		if err := linkTags(ctx, tx, book); err != nil {
+			return err
+		}
+		return recordChange(ctx, tx, models.ActionUpdate, current, book)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError(op, err)
+	}
+	return book, nil
+}
+
+// applyPatch returns the book resulting from applying patch to current.
+func applyPatch(op string, current *models.Book, patch Patch) (*models.Book, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := patch.Apply(doc)
+	if err != nil {
+		kind := ErrValidation
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			kind = ErrConflict
+		}
+		return nil, &Error{Op: op, Kind: kind, Err: err}
+	}
+
+	var book models.Book
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&book); err != nil {
+		return nil, &Error{Op: op, Kind: ErrValidation, Err: fmt.Errorf("patched book is invalid: %v", err)}
+	}
+	if book.ID != current.ID {
+		return nil, &Error{Op: op, Kind: ErrValidation, Err: errors.New("id cannot be changed")}
+	}
+	// the version and timestamps are maintained by the store
+	book.Version = current.Version
+	book.UpdatedAt = current.UpdatedAt
+	book.DeletedAt = current.DeletedAt
+	// keep the byline and the authors in step when only one of them changes
+	switch changed := !sameAuthors(book.Authors, current.Authors); {
+	case book.Author != current.Author && !changed:
+		book.Authors = nil
+	case book.Author == current.Author && changed:
+		book.Author = ""
+	}
+
+	book.Normalize()
+	if err := book.Validate(); err != nil {
+		return nil, &Error{Op: op, Kind: ErrValidation, Err: err}
+	}
+	return &book, nil
+}
+
+func sameAuthors(a, b []models.Author) bool {
+	return slices.EqualFunc(a, b, func(x, y models.Author) bool {
+		return x.ID == y.ID && x.Name == y.Name
+	})
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sample-app/models"
+)
+
+const (
+	// DefaultPageSize is the number of books listed when no limit is given.
+	DefaultPageSize = 20
+	// MaxPageSize is the largest accepted limit.
+	MaxPageSize = 100
+)
+
+// ListOptions controls pagination, sorting and filtering in BookService.ListBooks.
+type ListOptions struct {
+	// Limit is the page size, DefaultPageSize if zero.
+	Limit int
+	// Offset skips the given number of books. It cannot be combined with Cursor.
+	Offset int
+	// Cursor continues a listing from the NextCursor of a previous page.
+	Cursor string
+	// Sort is a field name (id, title or author), optionally prefixed
+	// with '-' for descending order. Defaults to "id".
+	Sort string
+
+	// Title and Author filter on exact values.
+	Title  string
+	Author string
+	// TitlePrefix and AuthorPrefix filter on case-insensitive prefixes.
+	TitlePrefix  string
+	AuthorPrefix string
+	// AuthorID lists the books linked to an author.
+	AuthorID uint
+	// Genre and Tag list the books with a genre or tag, given by name or slug.
+	Genre string
+	Tag   string
+
+	// IncludeDeleted lists deleted books along with active ones.
+	IncludeDeleted bool
+	// OnlyDeleted lists the books in the trash.
+	OnlyDeleted bool
+
+	// IncludeTotal requests the number of books matching the filters.
+	IncludeTotal bool
+}
+
+// BookList is a page of books returned by BookService.ListBooks.
+type BookList struct {
+	Books []models.Book
+	// Total is the number of books matching the filters, nil unless requested.
+	Total *int64
+	// NextCursor continues the listing, empty on the last page.
+	NextCursor string
+}
+
+// cursorToken is the decoded form of an opaque pagination cursor.
+// The sort order is part of the token so a cursor cannot be reused
+// with a different order.
+type cursorToken struct {
+	Sort  string `json:"s"`
+	ID    uint   `json:"i"`
+	Value string `json:"v,omitempty"`
+}
+
+func encodeCursor(sort string, c models.Cursor) string {
+	b, _ := json.Marshal(cursorToken{Sort: sort, ID: c.ID, Value: c.Value})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(sort, s string) (*models.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	var tok cursorToken
+	if err := json.Unmarshal(b, &tok); err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	if tok.Sort != sort {
+		return nil, fmt.Errorf("cursor was issued for sort %q, not %q", tok.Sort, sort)
+	}
+	return &models.Cursor{ID: tok.ID, Value: tok.Value}, nil
+}
+
+// sortOrder returns Sort, defaulting to ascending IDs.
+func (o ListOptions) sortOrder() string {
+	if o.Sort == "" {
+		return string(models.SortByID)
+	}
+	return o.Sort
+}
+
+// toQuery validates the options and converts them into a storage query.
+func (o ListOptions) toQuery() (models.BookQuery, error) {
+	q := models.BookQuery{
+		Title:        o.Title,
+		TitlePrefix:  o.TitlePrefix,
+		Author:       o.Author,
+		AuthorPrefix: o.AuthorPrefix,
+		AuthorID:     o.AuthorID,
+		Genre:        models.TagSlug(o.Genre),
+		Tag:          models.TagSlug(o.Tag),
+		Offset:       o.Offset,
+		Limit:        o.Limit,
+		CountTotal:   o.IncludeTotal,
+	}
+	switch {
+	case o.OnlyDeleted:
+		q.Visibility = models.DeletedBooks
+	case o.IncludeDeleted:
+		q.Visibility = models.AllBooks
+	}
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultPageSize
+	case q.Limit < 0 || q.Limit > MaxPageSize:
+		return q, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	}
+	if q.Offset < 0 {
+		return q, errors.New("offset must not be negative")
+	}
+
+	sort := o.sortOrder()
+	q.SortBy = models.SortField(strings.TrimPrefix(sort, "-"))
+	q.Descending = strings.HasPrefix(sort, "-")
+	if !q.SortBy.Valid() {
+		return q, fmt.Errorf("cannot sort by %q", q.SortBy)
+	}
+
+	if o.Cursor != "" {
+		if o.Offset != 0 {
+			return q, errors.New("cursor and offset cannot be combined")
+		}
+		after, err := decodeCursor(sort, o.Cursor)
+		if err != nil {
+			return q, err
+		}
+		q.After = after
+	}
+	return q, nil
+}
+
+// newSearchQuery validates a search and converts it into a storage query.
+func newSearchQuery(query string, limit int) (models.SearchQuery, error) {
+	q := models.SearchQuery{Terms: models.SearchTerms(query), Limit: limit}
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultPageSize
+	case q.Limit < 0 || q.Limit > MaxPageSize:
+		return q, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	}
+	if len(q.Terms) == 0 {
+		return q, errors.New("query must contain at least one word")
+	}
+	return q, nil
+}
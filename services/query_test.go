@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sample-app/models"
+)
+
+func TestListOptionsValidation(t *testing.T) {
+	cursor := encodeCursor("title", models.Cursor{ID: 1, Value: "Dune"})
+	tests := map[string]ListOptions{
+		"limit too large":       {Limit: MaxPageSize + 1},
+		"negative limit":        {Limit: -1},
+		"negative offset":       {Offset: -1},
+		"unknown sort":          {Sort: "price"},
+		"cursor and offset":     {Cursor: cursor, Sort: "title", Offset: 2},
+		"cursor of other order": {Cursor: cursor, Sort: "-title"},
+		"malformed cursor":      {Cursor: "not a cursor!"},
+	}
+	s := NewBookService(models.NewMemoryStore(), 0)
+	for name, opts := range tests {
+		if _, err := s.ListBooks(context.Background(), opts); !errors.Is(err, ErrValidation) {
+			t.Errorf("%s: got %v, want ErrValidation", name, err)
+		}
+	}
+}
+
+func TestListBooksPagesWithCursors(t *testing.T) {
+	ctx := context.Background()
+	s := NewBookService(models.NewMemoryStore(), 0)
+	for _, title := range []string{"E", "B", "D", "A", "C"} {
+		if err := s.CreateBook(ctx, &models.Book{Title: title, Author: "X"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	opts := ListOptions{Limit: 2, Sort: "-title", IncludeTotal: true}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("the listing does not end")
+		}
+		list, err := s.ListBooks(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if list.Total == nil || *list.Total != 5 {
+			t.Errorf("got total %v, want 5", list.Total)
+		}
+		for _, book := range list.Books {
+			got = append(got, book.Title)
+		}
+		if list.NextCursor == "" {
+			break
+		}
+		opts.Cursor = list.NextCursor
+	}
+	if len(got) != 5 || got[0] != "E" || got[4] != "A" {
+		t.Errorf("got %v, want E to A", got)
+	}
+}
+
+func TestSearchBooksValidation(t *testing.T) {
+	s := NewBookService(models.NewMemoryStore(), 0)
+	for _, tt := range []struct {
+		query string
+		limit int
+	}{
+		{"", 0},
+		{" -- ", 0},
+		{"dune", MaxPageSize + 1},
+		{"dune", -1},
+	} {
+		if _, err := s.SearchBooks(context.Background(), tt.query, tt.limit); !errors.Is(err, ErrValidation) {
+			t.Errorf("query %q limit %d: got %v, want ErrValidation", tt.query, tt.limit, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"sample-app/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ListTags returns the genres and tags of the catalog, or only those of
+// kind if it is not empty.
+func (s *BookService) ListTags(ctx context.Context, kind string) ([]models.Tag, error) {
+	ctx, span := s.tracer.Start(ctx, "ListTags")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tag.kind", kind))
+
+	switch kind {
+	case "", models.TagKindGenre, models.TagKindTag:
+	default:
+		err := fmt.Errorf("kind must be %q or %q", models.TagKindGenre, models.TagKindTag)
+		span.RecordError(err)
+		return nil, &Error{Op: "list tags", Kind: ErrValidation, Err: err}
+	}
+
+	tags, err := s.store.ListTags(ctx, kind)
+	if err != nil {
+		span.RecordError(err)
+		return nil, wrapError("list tags", err)
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	span.SetAttributes(attribute.Int("tags.count", len(tags)))
+	return tags, nil
+}
+
+// linkTags links a stored book to its genres and tags, creating the ones
+// that do not exist yet, and replaces their names in book with the names
+// they were first created with.
+func linkTags(ctx context.Context, tx models.Interface, book *models.Book) error {
+	var ids []uint
+	link := func(kind string, names []string) ([]string, error) {
+		var linked []string
+		for _, name := range names {
+			tag := models.Tag{Kind: kind, Name: name}
+			if err := tx.FirstOrCreateTag(ctx, &tag); err != nil {
+				return nil, err
+			}
+			if !slices.Contains(ids, tag.ID) {
+				ids = append(ids, tag.ID)
+				linked = append(linked, tag.Name)
+			}
+		}
+		return linked, nil
+	}
+
+	var err error
+	if book.Genres, err = link(models.TagKindGenre, book.Genres); err != nil {
+		return err
+	}
+	if book.Tags, err = link(models.TagKindTag, book.Tags); err != nil {
+		return err
+	}
+	return tx.SetBookTags(ctx, book.ID, ids)
+}
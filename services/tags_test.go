@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"sample-app/models"
+)
+
+func TestBookServiceTagsBooks(t *testing.T) {
+	ctx := context.Background()
+	s := NewBookService(models.NewMemoryStore(), 0)
+
+	book := &models.Book{Title: "Dune", Author: "Frank Herbert", Genres: []string{"Science Fiction"}, Tags: []string{"Classic"}}
+	if err := s.CreateBook(ctx, book); err != nil {
+		t.Fatal(err)
+	}
+	genres, err := s.ListTags(ctx, models.TagKindGenre)
+	if err != nil || len(genres) != 1 || genres[0].Slug != "science-fiction" {
+		t.Fatalf("got genres %+v, %v; want science-fiction", genres, err)
+	}
+	all, err := s.ListTags(ctx, "")
+	if err != nil || len(all) != 2 {
+		t.Errorf("got tags %+v, %v; want the genre and the tag", all, err)
+	}
+	if _, err := s.ListTags(ctx, "shelf"); !errors.Is(err, ErrValidation) {
+		t.Errorf("got %v for an unknown kind, want ErrValidation", err)
+	}
+}